@@ -1,7 +1,8 @@
-package bfe
+package main
 
 import (
 	"flag"
+	"time"
 )
 
 import (
@@ -10,14 +11,48 @@ import (
 
 import (
 	"github.com/baidu/ingress-bfe/internal/config"
+	"github.com/baidu/ingress-bfe/internal/controller"
 )
 
-func parseFlags() config.Configuration  {
+func parseFlags() config.Configuration {
 	namespace := flag.String("namespace", coreV1.NamespaceAll, "Namespace the controller watches for updates to Kubernetes objects. This includes Ingresses, Services and all configuration resources. All namespaces are watched if this parameter is left empty.")
 
+	kubeConfigFile := flag.String("kubeconfig", "", "Path to a kubeconfig file. In-cluster config is used if this parameter is left empty.")
+
+	maxConfigVersions := flag.Int("max-config-versions", config.DefMaxVersions, "Number of BFE config versions retained for rollback and debugging.")
+
+	dryRun := flag.Bool("dry-run", false, "Render BFE config without starting or reloading BFE. Rendered config is printed to stdout as a unified diff against the previous render, unless --dry-run-dir is set.")
+	dryRunDir := flag.String("dry-run-dir", "", "Directory the full rendered BFE config is written to in dry run mode.")
+
+	defaultBackendService := flag.String("default-backend-service", "", "Service used to serve HTTP requests not matching any Ingress rule, in the form namespace/name or namespace/name:port, port is a number or name of a service port. The first port of the service is used if port is omitted. A built-in backend answering 404 is used if this parameter is left empty.")
+	defaultBackendPort := flag.Int("default-backend-port", controller.DefBuiltinBackendPort, "Port the built-in default backend listens on at 127.0.0.1.")
+
+	externalNameResolvePeriod := flag.Duration("external-name-resolve-period", 30*time.Second, "Interval of re-resolving hosts of ExternalName Services used as Ingress backends.")
+
+	validationWebhook := flag.String("validating-webhook", "", "Address the validating admission webhook server of Ingresses listens on, e.g. :8443. The webhook is disabled if this parameter is left empty.")
+	validationWebhookCert := flag.String("validating-webhook-certificate", "", "Path of the certificate file of the validating admission webhook server.")
+	validationWebhookKey := flag.String("validating-webhook-key", "", "Path of the key file of the validating admission webhook server.")
+
+	configMap := flag.String("configmap", "", "Name of the ConfigMap containing global settings, in the form namespace/name.")
+
+	metricsAddr := flag.String("metrics-addr", ":10254", "Address metrics and debug endpoints listen on, e.g. /debug/vars of reload counters, /debug/config-versions of retained BFE config versions and /debug/conflicts of conflicting Ingress paths. The endpoints are disabled if this parameter is left empty.")
+
 	flag.Parse()
 
 	return config.Configuration{
-		Namespace:*namespace,
+		Namespace:         *namespace,
+		KubeConfigFile:    *kubeConfigFile,
+		MaxConfigVersions: *maxConfigVersions,
+		DryRun:            *dryRun,
+		DryRunDir:         *dryRunDir,
+
+		DefaultBackendService:     *defaultBackendService,
+		DefaultBackendPort:        *defaultBackendPort,
+		ExternalNameResolvePeriod: *externalNameResolvePeriod,
+		ValidationWebhook:         *validationWebhook,
+		ValidationWebhookCertPath: *validationWebhookCert,
+		ValidationWebhookKeyPath:  *validationWebhookKey,
+		ConfigMap:                 *configMap,
+		MetricsAddr:               *metricsAddr,
 	}
-}
\ No newline at end of file
+}
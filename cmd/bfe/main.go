@@ -1,8 +1,12 @@
-package bfe
+package main
 
 import (
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/baidu/ingress-bfe/internal/controller"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes"
@@ -16,29 +20,28 @@ const (
 )
 
 func main() {
-	// 	cfg := parseFlags()
+	klog.InitFlags(nil)
+	cfg := parseFlags()
 
-	// 	signalChan := make(chan os.Signal, 1)
-	// 	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+	signalChan := make(chan os.Signal, 1)
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
 
-	// 	kubeClient, err := createApiserverClient()
-	// 	if err != nil {
-	// 		klog.Exitf("Could not establish a connection to the Kubernetes API Server. err:%v", err)
-	// 	}
-
-	// 	restClient := kubeClient.NetworkingV1beta1().RESTClient()
-	// 	c := controller.NewBfeController(kubeClient, restClient, cfg)
-
-	// 	stopCh := make(chan struct{}, 1)
-	// 	go c.Run(stopCh)
+	kubeClient, err := createApiserverClient(cfg.KubeConfigFile)
+	if err != nil {
+		klog.Exitf("Could not establish a connection to the Kubernetes API Server. err:%v", err)
+	}
 
-	// 	<-signalChan
-	// 	c.Exit()
-	// }
+	c := controller.NewBfeController(kubeClient, cfg)
+	go c.Run()
 
+	<-signalChan
+	if err := c.Stop(); err != nil {
+		klog.Warningf("Error stopping controller: %v", err)
+	}
 }
-func createApiserverClient() (*kubernetes.Clientset, error) {
-	cfg, err := clientcmd.BuildConfigFromFlags("", "")
+
+func createApiserverClient(kubeConfigFile string) (*kubernetes.Clientset, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeConfigFile)
 	if err != nil {
 		return nil, err
 	}
@@ -72,8 +75,6 @@ func createApiserverClient() (*kubernetes.Clientset, error) {
 		}
 
 		lastErr = err
-		klog.Infof("Running in Kubernetes Cluster version v%v.%v (%v) - git (%v) commit %v - platform %v",
-			v.Major, v.Minor, v.GitVersion, v.GitTreeState, v.GitCommit, v.Platform)
 		retries++
 		return false, nil
 	})
@@ -81,6 +82,9 @@ func createApiserverClient() (*kubernetes.Clientset, error) {
 		return nil, lastErr
 	}
 
+	klog.Infof("Running in Kubernetes Cluster version v%v.%v (%v) - git (%v) commit %v - platform %v",
+		v.Major, v.Minor, v.GitVersion, v.GitTreeState, v.GitCommit, v.Platform)
+
 	if retries > 0 {
 		klog.Warningf("Initial connection to the Kubernetes API server was retried %d times.", retries)
 	}
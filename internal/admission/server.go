@@ -0,0 +1,117 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// maxRequestSize limits body of admission requests
+	maxRequestSize = 3 * 1024 * 1024
+
+	defReadTimeout  = 10 * time.Second
+	defWriteTimeout = 10 * time.Second
+)
+
+// IngressChecker checks an Ingress before it is admitted
+type IngressChecker interface {
+	// CheckIngress returns error if ingress would not be translated into
+	// BFE config as it is
+	CheckIngress(ing *networking.Ingress) error
+}
+
+// Handler is a validating admission webhook of Ingresses. AdmissionReview of
+// admission.k8s.io/v1 and v1beta1 are accepted, response is of the same
+// version as request.
+type Handler struct {
+	Checker IngressChecker
+}
+
+// NewServer returns a webhook server of checker listening on addr
+func NewServer(addr string, checker IngressChecker) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/", &Handler{Checker: checker})
+	return &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  defReadTimeout,
+		WriteTimeout: defWriteTimeout,
+	}
+}
+
+// ServeHTTP handles an AdmissionReview request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("read request error: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// v1beta1 and v1 AdmissionReview share the same schema
+	review := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+		http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	data, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("marshal response error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// review returns response of admission request, requests of resources
+// other than Ingress are allowed
+func (h *Handler) review(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Resource.Resource != "ingresses" || req.Operation == admissionv1.Delete {
+		return &admissionv1.AdmissionResponse{Allowed: true}
+	}
+
+	// extensions/v1beta1 and networking.k8s.io/v1beta1 Ingress share the
+	// same schema
+	ing := &networking.Ingress{}
+	if err := json.Unmarshal(req.Object.Raw, ing); err != nil {
+		return deny(fmt.Sprintf("invalid Ingress: %v", err))
+	}
+	if ing.Namespace == "" {
+		ing.Namespace = req.Namespace
+	}
+
+	if err := h.Checker.CheckIngress(ing); err != nil {
+		klog.Infof("Ingress %v/%v rejected: %v", ing.Namespace, ing.Name, err)
+		return deny(err.Error())
+	}
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func deny(msg string) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: msg,
+		},
+	}
+}
@@ -0,0 +1,146 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// fakeChecker rejects Ingresses of name bad
+type fakeChecker struct {
+	checked []string
+}
+
+func (c *fakeChecker) CheckIngress(ing *networking.Ingress) error {
+	c.checked = append(c.checked, ing.Namespace+"/"+ing.Name)
+	if ing.Name == "bad" {
+		return errors.New("bad ingress")
+	}
+	return nil
+}
+
+func newReview(resource string, op admissionv1.Operation, obj interface{}) []byte {
+	raw, _ := json.Marshal(obj)
+	data, _ := json.Marshal(admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid-1",
+			Resource:  metav1.GroupVersionResource{Resource: resource},
+			Namespace: "ns",
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	return data
+}
+
+func TestHandler(t *testing.T) {
+	ingress := func(name string) *networking.Ingress {
+		return &networking.Ingress{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	}
+	tests := []struct {
+		name    string
+		method  string
+		body    []byte
+		status  int
+		allowed bool
+		checked []string
+	}{
+		{
+			name:    "allowed",
+			method:  http.MethodPost,
+			body:    newReview("ingresses", admissionv1.Create, ingress("good")),
+			status:  http.StatusOK,
+			allowed: true,
+			checked: []string{"ns/good"},
+		},
+		{
+			name:    "denied",
+			method:  http.MethodPost,
+			body:    newReview("ingresses", admissionv1.Update, ingress("bad")),
+			status:  http.StatusOK,
+			checked: []string{"ns/bad"},
+		},
+		{
+			name:    "deletion",
+			method:  http.MethodPost,
+			body:    newReview("ingresses", admissionv1.Delete, ingress("bad")),
+			status:  http.StatusOK,
+			allowed: true,
+		},
+		{
+			name:    "other resource",
+			method:  http.MethodPost,
+			body:    newReview("services", admissionv1.Create, ingress("bad")),
+			status:  http.StatusOK,
+			allowed: true,
+		},
+		{
+			name:   "invalid ingress",
+			method: http.MethodPost,
+			body:   newReview("ingresses", admissionv1.Create, map[string]interface{}{"spec": "x"}),
+			status: http.StatusOK,
+		},
+		{
+			name:   "invalid review",
+			method: http.MethodPost,
+			body:   []byte("{"),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "no request",
+			method: http.MethodPost,
+			body:   []byte("{}"),
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "method",
+			method: http.MethodGet,
+			status: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, test := range tests {
+		checker := &fakeChecker{}
+		rec := httptest.NewRecorder()
+		(&Handler{Checker: checker}).ServeHTTP(rec, httptest.NewRequest(test.method, "/", bytes.NewReader(test.body)))
+
+		if rec.Code != test.status {
+			t.Errorf("%v: status %d, want %d", test.name, rec.Code, test.status)
+			continue
+		}
+		if len(checker.checked) != len(test.checked) || (len(test.checked) > 0 && checker.checked[0] != test.checked[0]) {
+			t.Errorf("%v: checked %v, want %v", test.name, checker.checked, test.checked)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		review := &admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(rec.Body.Bytes(), review); err != nil {
+			t.Errorf("%v: unmarshal response error: %v", test.name, err)
+			continue
+		}
+		if review.Request != nil {
+			t.Errorf("%v: request is not removed from response", test.name)
+		}
+		if review.Response == nil || review.Response.UID != "uid-1" {
+			t.Errorf("%v: response of uid-1 expected, got %+v", test.name, review.Response)
+			continue
+		}
+		if review.Response.Allowed != test.allowed {
+			t.Errorf("%v: allowed is %v, want %v", test.name, review.Response.Allowed, test.allowed)
+		}
+		if !test.allowed && (review.Response.Result == nil || review.Response.Result.Code != http.StatusUnprocessableEntity) {
+			t.Errorf("%v: code 422 expected, result %+v", test.name, review.Response.Result)
+		}
+	}
+}
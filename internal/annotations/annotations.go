@@ -0,0 +1,91 @@
+package annotations
+
+import (
+	"fmt"
+	"sort"
+
+	networking "k8s.io/api/networking/v1beta1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Annotations is the typed config parsed from annotations of an Ingress
+type Annotations struct {
+	Canary          Canary
+	Router          Router
+	RouterCondition string
+	Rewrite         Rewrite
+	Redirect        Redirect
+	Header          Header
+	Auth            Auth
+	IPAccess        IPAccess
+	CORS            CORS
+}
+
+// Parser parses annotations of a feature into Annotations
+type Parser struct {
+	// Name of the feature
+	Name string
+	// Keys are annotation names without prefix, Parse is called only if
+	// one of them is set
+	Keys []string
+	// Parse sets fields of the feature in Annotations
+	Parse func(ing *networking.Ingress, a *Annotations) error
+}
+
+// parsers are registered parsers in registration order
+var parsers []Parser
+
+// register adds parser of a feature, it is called in init of feature files
+func register(p Parser) {
+	parsers = append(parsers, p)
+}
+
+// Keys returns annotation names with prefix of all registered parsers
+func Keys() []string {
+	var keys []string
+	for _, p := range parsers {
+		for _, key := range p.Keys {
+			keys = append(keys, GetAnnotationWithPrefix(key))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Features returns names of features whose annotations are set on ing, in
+// registration order
+func Features(ing *networking.Ingress) []string {
+	var names []string
+	for _, p := range parsers {
+		if hasAnyAnnotation(ing, p.Keys) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// Extract parses annotations of ingress by all registered parsers. Errors
+// of all parsers are aggregated and prefixed by the feature name, fields of
+// failed features are undefined.
+func Extract(ing *networking.Ingress) (*Annotations, error) {
+	a := &Annotations{}
+	var errs []error
+	for _, p := range parsers {
+		if !hasAnyAnnotation(ing, p.Keys) {
+			continue
+		}
+		if err := p.Parse(ing, a); err != nil {
+			errs = append(errs, fmt.Errorf("%v: %v", p.Name, err))
+		}
+	}
+	return a, utilerrors.NewAggregate(errs)
+}
+
+func hasAnyAnnotation(ing *networking.Ingress, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := ing.GetAnnotations()[GetAnnotationWithPrefix(key)]; ok {
+			return true
+		}
+	}
+	return false
+}
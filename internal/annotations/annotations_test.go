@@ -0,0 +1,85 @@
+package annotations
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("Keys() = %v, want sorted", keys)
+	}
+
+	registered := make(map[string]bool)
+	for _, key := range keys {
+		if registered[key] {
+			t.Errorf("key %v is registered more than once", key)
+		}
+		registered[key] = true
+	}
+
+	for _, key := range []string{CanaryKey, RouterConditionKey, SSLRedirectCodeKey, AllowlistSourceRangeKey, EnableCORSKey} {
+		if !registered[GetAnnotationWithPrefix(key)] {
+			t.Errorf("Keys() does not contain %v", GetAnnotationWithPrefix(key))
+		}
+	}
+}
+
+func TestExtract(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		RouterConditionKey:      "req_cip_trusted()",
+		AllowlistSourceRangeKey: "10.0.0.0/8",
+	})
+	a, err := Extract(ing)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if a.RouterCondition != "req_cip_trusted()" {
+		t.Errorf("RouterCondition = %q, want %q", a.RouterCondition, "req_cip_trusted()")
+	}
+	if len(a.IPAccess.Allowlist) != 1 || a.IPAccess.Allowlist[0].String() != "10.0.0.0/8" {
+		t.Errorf("IPAccess.Allowlist = %v, want [10.0.0.0/8]", a.IPAccess.Allowlist)
+	}
+	if a.Redirect.SSLRedirect != nil {
+		t.Errorf("Redirect is parsed without its annotations")
+	}
+}
+
+func TestExtractNoAnnotations(t *testing.T) {
+	a, err := Extract(buildIngress(nil))
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if a.RouterCondition != "" || a.IPAccess.Allowlist != nil {
+		t.Errorf("Extract() = %+v, want empty Annotations", a)
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		RouterConditionKey:      "req_foo()",
+		AllowlistSourceRangeKey: "foo",
+		SSLRedirectKey:          "yes",
+		EnableCORSKey:           "true",
+	})
+	_, err := Extract(ing)
+	if err == nil {
+		t.Fatalf("Extract() of invalid annotations succeeded")
+	}
+	agg, ok := err.(utilerrors.Aggregate)
+	if !ok {
+		t.Fatalf("Extract() error = %T, want Aggregate", err)
+	}
+	if got := len(agg.Errors()); got != 3 {
+		t.Errorf("Extract() returned %d errors, want 3: %v", got, err)
+	}
+	for _, name := range []string{"router.condition", "ipaccess", "redirect"} {
+		if !strings.Contains(err.Error(), name+": ") {
+			t.Errorf("Extract() error %q does not name feature %v", err, name)
+		}
+	}
+}
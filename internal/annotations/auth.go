@@ -0,0 +1,90 @@
+package annotations
+
+import (
+	"fmt"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// AuthTypeKey is the authentication type, only "basic" is supported
+	AuthTypeKey = "auth-type"
+	// AuthSecretKey is the name of Secret in the namespace of Ingress whose
+	// "auth" key holds users in htpasswd format
+	AuthSecretKey = "auth-secret"
+	// AuthRealmKey is the realm of basic authentication
+	AuthRealmKey = "auth-realm"
+
+	// AuthTypeBasic is the basic authentication type
+	AuthTypeBasic = "basic"
+	defAuthRealm  = "Authentication Required"
+)
+
+func init() {
+	register(Parser{
+		Name: "auth",
+		Keys: []string{AuthTypeKey, AuthSecretKey, AuthRealmKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.Auth, err = ParseAuth(ing)
+			return err
+		},
+	})
+}
+
+// Auth is the basic authentication of an Ingress
+type Auth struct {
+	// Secret is namespace/name of the Secret, empty if not set
+	Secret string
+	Realm  string
+}
+
+// ParseAuth parses authentication annotations of ingress
+func ParseAuth(ing *networking.Ingress) (Auth, error) {
+	var a Auth
+
+	authType, err := GetStringAnnotation(AuthTypeKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return a, err
+	}
+	if authType != "" && authType != AuthTypeBasic {
+		return a, fmt.Errorf("the annotation %v must be %v (%v)", GetAnnotationWithPrefix(AuthTypeKey), AuthTypeBasic, authType)
+	}
+
+	secret, err := GetAuthSecret(ing)
+	if err != nil {
+		return a, err
+	}
+	if secret == "" {
+		return a, fmt.Errorf("the annotation %v is required", GetAnnotationWithPrefix(AuthSecretKey))
+	}
+	a.Secret = secret
+
+	a.Realm, err = GetStringAnnotation(AuthRealmKey, ing)
+	if err == ErrMissingAnnotations {
+		a.Realm = defAuthRealm
+	} else if err != nil {
+		return a, err
+	}
+	if strings.ContainsAny(a.Realm, "\"\r\n") {
+		return a, fmt.Errorf("the annotation %v contains an invalid character", GetAnnotationWithPrefix(AuthRealmKey))
+	}
+
+	return a, nil
+}
+
+// GetAuthSecret returns namespace/name of the auth Secret of ingress, empty
+// if not set. Secrets of other namespaces are not allowed.
+func GetAuthSecret(ing *networking.Ingress) (string, error) {
+	name, err := GetStringAnnotation(AuthSecretKey, ing)
+	if err == ErrMissingAnnotations {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if strings.Contains(name, "/") {
+		return "", fmt.Errorf("the annotation %v must be a Secret name in namespace %v (%v)", GetAnnotationWithPrefix(AuthSecretKey), ing.Namespace, name)
+	}
+	return fmt.Sprintf("%v/%v", ing.Namespace, name), nil
+}
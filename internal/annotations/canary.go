@@ -0,0 +1,88 @@
+package annotations
+
+import (
+	"fmt"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// CanaryKey marks an Ingress as canary of the Ingress serving the same
+	// host and path
+	CanaryKey = "canary"
+	// CanaryWeightKey is the percentage of requests sent to canary
+	CanaryWeightKey = "canary-weight"
+	// CanaryByHeaderKey is the header forcing requests to canary
+	CanaryByHeaderKey = "canary-by-header"
+	// CanaryByHeaderValueKey is the header value forcing requests to
+	// canary, CanaryAlways if not set
+	CanaryByHeaderValueKey = "canary-by-header-value"
+	// CanaryByCookieKey is the cookie forcing requests to canary if its
+	// value is CanaryAlways
+	CanaryByCookieKey = "canary-by-cookie"
+
+	// CanaryAlways is the header or cookie value forcing requests to canary
+	CanaryAlways = "always"
+)
+
+func init() {
+	register(Parser{
+		Name: "canary",
+		Keys: []string{CanaryKey, CanaryWeightKey, CanaryByHeaderKey, CanaryByHeaderValueKey, CanaryByCookieKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.Canary, err = ParseCanary(ing)
+			return err
+		},
+	})
+}
+
+// Canary is the canary config of an Ingress
+type Canary struct {
+	Enabled     bool
+	Weight      int
+	Header      string
+	HeaderValue string
+	Cookie      string
+}
+
+// ParseCanary parses canary annotations of ingress. A zero Canary is
+// returned if the ingress is not a canary.
+func ParseCanary(ing *networking.Ingress) (Canary, error) {
+	var c Canary
+
+	enabled, err := GetBoolAnnotation(CanaryKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+	if !enabled {
+		return c, nil
+	}
+	c.Enabled = true
+
+	c.Weight, err = GetIntAnnotation(CanaryWeightKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+	if c.Weight < 0 || c.Weight > 100 {
+		return c, fmt.Errorf("the annotation %v must be between 0 and 100 (%v)", GetAnnotationWithPrefix(CanaryWeightKey), c.Weight)
+	}
+
+	c.Header, err = GetStringAnnotation(CanaryByHeaderKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+	c.HeaderValue, err = GetStringAnnotation(CanaryByHeaderValueKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+	if c.HeaderValue == "" {
+		c.HeaderValue = CanaryAlways
+	}
+
+	c.Cookie, err = GetStringAnnotation(CanaryByCookieKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+
+	return c, nil
+}
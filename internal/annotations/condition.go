@@ -0,0 +1,283 @@
+package annotations
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strconv"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// RouterConditionKey is a raw BFE condition expression, e.g.
+	// req_method_in("GET|HEAD") && !req_cip_trusted()
+	RouterConditionKey = "router.condition"
+)
+
+func init() {
+	register(Parser{
+		Name: "router.condition",
+		Keys: []string{RouterConditionKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.RouterCondition, err = ParseRouterCondition(ing)
+			return err
+		},
+	})
+}
+
+// argKind is the kind of argument of a condition primitive
+type argKind int
+
+const (
+	argString argKind = iota
+	argBool
+)
+
+func (k argKind) String() string {
+	if k == argBool {
+		return "bool"
+	}
+	return "string"
+}
+
+// condPrimitives are the BFE condition primitives allowed in annotation,
+// mapped to kinds of their arguments
+var condPrimitives = map[string][]argKind{
+	"default_t": {},
+
+	"req_host_in":   {argString},
+	"req_method_in": {argString},
+	"req_port_in":   {argString},
+	"req_tag_match": {argString, argString},
+
+	"req_proto_secure":   {},
+	"req_url_regmatch":   {argString},
+	"req_path_in":        {argString, argBool},
+	"req_path_prefix_in": {argString, argBool},
+	"req_path_suffix_in": {argString, argBool},
+	"req_path_regmatch":  {argString},
+
+	"req_path_element_prefix_in": {argString, argBool},
+
+	"req_query_exist":           {},
+	"req_query_key_in":          {argString},
+	"req_query_key_prefix_in":   {argString},
+	"req_query_value_in":        {argString, argString, argBool},
+	"req_query_value_prefix_in": {argString, argString, argBool},
+	"req_query_value_suffix_in": {argString, argString, argBool},
+	"req_query_value_hash_in":   {argString, argString, argBool},
+
+	"req_cookie_key_in":          {argString},
+	"req_cookie_value_in":        {argString, argString, argBool},
+	"req_cookie_value_prefix_in": {argString, argString, argBool},
+	"req_cookie_value_suffix_in": {argString, argString, argBool},
+	"req_cookie_value_hash_in":   {argString, argString, argBool},
+
+	"req_header_key_in":        {argString},
+	"req_header_value_in":      {argString, argString, argBool},
+	"req_header_prefix_in":     {argString, argString, argBool},
+	"req_header_suffix_in":     {argString, argString, argBool},
+	"req_header_value_hash_in": {argString, argString, argBool},
+
+	"req_cip_trusted": {},
+	"req_cip_range":   {argString, argString},
+	"req_cip_hash_in": {argString},
+	"req_vip_in":      {argString},
+	"req_vip_range":   {argString, argString},
+
+	"ses_sip_range":       {argString, argString},
+	"ses_vip_range":       {argString, argString},
+	"ses_tls_sni_in":      {argString},
+	"ses_tls_client_auth": {},
+}
+
+// ParseRouterCondition returns the raw condition annotation of ingress after
+// validating it, empty if not set
+func ParseRouterCondition(ing *networking.Ingress) (string, error) {
+	cond, err := GetStringAnnotation(RouterConditionKey, ing)
+	if err == ErrMissingAnnotations {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := ValidateCondition(cond); err != nil {
+		return "", fmt.Errorf("the annotation %v contains an invalid condition: %v", GetAnnotationWithPrefix(RouterConditionKey), err)
+	}
+	return cond, nil
+}
+
+// ValidateCondition checks syntax of BFE condition expression, and that it
+// only calls allowed primitives with arguments of right kinds:
+//
+//	expr    = term { "||" term }
+//	term    = factor { "&&" factor }
+//	factor  = "!" factor | "(" expr ")" | primitive "(" [ arg { "," arg } ] ")"
+//	arg     = string | "true" | "false"
+//
+// Strings are Go string literals, as BFE scans conditions with go/scanner.
+func ValidateCondition(cond string) error {
+	p := &condParser{}
+	if err := p.init(cond); err != nil {
+		return err
+	}
+	if err := p.parseExpr(); err != nil {
+		return err
+	}
+	if p.tok != token.EOF {
+		return p.errorf("unexpected %v", p.tokString())
+	}
+	return nil
+}
+
+// condParser is a recursive descent parser of BFE condition
+type condParser struct {
+	scanner scanner.Scanner
+	errs    scanner.ErrorList
+
+	pos token.Pos
+	tok token.Token
+	lit string
+}
+
+func (p *condParser) init(cond string) error {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(cond))
+	p.scanner.Init(file, []byte(cond), func(pos token.Position, msg string) {
+		p.errs.Add(pos, msg)
+	}, 0)
+	p.next()
+	return p.scanErr()
+}
+
+func (p *condParser) next() {
+	p.pos, p.tok, p.lit = p.scanner.Scan()
+	// semicolons inserted by scanner at newline or end of input are ignored
+	for p.tok == token.SEMICOLON && p.lit == "\n" {
+		p.pos, p.tok, p.lit = p.scanner.Scan()
+	}
+}
+
+func (p *condParser) scanErr() error {
+	if len(p.errs) > 0 {
+		return p.errs[0]
+	}
+	return nil
+}
+
+func (p *condParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", int(p.pos)-1, fmt.Sprintf(format, args...))
+}
+
+func (p *condParser) tokString() string {
+	if p.lit != "" {
+		return strconv.Quote(p.lit)
+	}
+	return p.tok.String()
+}
+
+func (p *condParser) expect(tok token.Token) error {
+	if p.tok != tok {
+		return p.errorf("expected %v, found %v", tok, p.tokString())
+	}
+	p.next()
+	return p.scanErr()
+}
+
+func (p *condParser) parseExpr() error {
+	if err := p.parseTerm(); err != nil {
+		return err
+	}
+	for p.tok == token.LOR {
+		p.next()
+		if err := p.parseTerm(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *condParser) parseTerm() error {
+	if err := p.parseFactor(); err != nil {
+		return err
+	}
+	for p.tok == token.LAND {
+		p.next()
+		if err := p.parseFactor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *condParser) parseFactor() error {
+	if err := p.scanErr(); err != nil {
+		return err
+	}
+
+	switch p.tok {
+	case token.NOT:
+		p.next()
+		return p.parseFactor()
+
+	case token.LPAREN:
+		p.next()
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+		return p.expect(token.RPAREN)
+
+	case token.IDENT:
+		return p.parseCall()
+
+	default:
+		return p.errorf("unexpected %v", p.tokString())
+	}
+}
+
+func (p *condParser) parseCall() error {
+	name := p.lit
+	kinds, ok := condPrimitives[name]
+	if !ok {
+		return p.errorf("primitive %v is not allowed", name)
+	}
+	p.next()
+	if err := p.expect(token.LPAREN); err != nil {
+		return err
+	}
+
+	var n int
+	for p.tok != token.RPAREN {
+		if n > 0 {
+			if err := p.expect(token.COMMA); err != nil {
+				return err
+			}
+		}
+		if n >= len(kinds) {
+			return p.errorf("too many arguments of %v, %d expected", name, len(kinds))
+		}
+		if err := p.parseArg(kinds[n]); err != nil {
+			return fmt.Errorf("argument %d of %v: %v", n+1, name, err)
+		}
+		n++
+	}
+	if n < len(kinds) {
+		return p.errorf("too few arguments of %v, %d expected", name, len(kinds))
+	}
+	return p.expect(token.RPAREN)
+}
+
+func (p *condParser) parseArg(kind argKind) error {
+	switch {
+	case kind == argString && p.tok == token.STRING:
+		if _, err := strconv.Unquote(p.lit); err != nil {
+			return p.errorf("invalid string %v", p.lit)
+		}
+	case kind == argBool && p.tok == token.IDENT && (p.lit == "true" || p.lit == "false"):
+	default:
+		return p.errorf("expected %v, found %v", kind, p.tokString())
+	}
+	p.next()
+	return p.scanErr()
+}
@@ -0,0 +1,138 @@
+package annotations
+
+import (
+	"strings"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateCondition(t *testing.T) {
+	tests := []struct {
+		name string
+		cond string
+		// wantErr is a substring of the expected error, empty if valid
+		wantErr string
+	}{
+		{
+			name: "primitive without arguments",
+			cond: "default_t()",
+		},
+		{
+			name: "nested and, or and not",
+			cond: `req_method_in("GET|HEAD") && !(req_cip_trusted() || req_path_prefix_in("/api", false))`,
+		},
+		{
+			name: "double negation and newline",
+			cond: "!!req_proto_secure() &&\n" + `req_header_value_in("X-Env", "gray", true)`,
+		},
+		{
+			name: "raw string",
+			cond: "req_url_regmatch(`^/v[0-9]+/`)",
+		},
+		{
+			name:    "empty",
+			cond:    "",
+			wantErr: "unexpected EOF",
+		},
+		{
+			name:    "unknown primitive",
+			cond:    `req_foo_in("a")`,
+			wantErr: "primitive req_foo_in is not allowed",
+		},
+		{
+			name:    "too few arguments",
+			cond:    `req_path_in("/a")`,
+			wantErr: "too few arguments of req_path_in, 2 expected",
+		},
+		{
+			name:    "too many arguments",
+			cond:    `req_host_in("a.com", "b.com")`,
+			wantErr: "too many arguments of req_host_in, 1 expected",
+		},
+		{
+			name:    "bool instead of string",
+			cond:    `req_host_in(true)`,
+			wantErr: "argument 1 of req_host_in",
+		},
+		{
+			name:    "string instead of bool",
+			cond:    `req_path_in("/a", "false")`,
+			wantErr: "expected bool",
+		},
+		{
+			name:    "unterminated string",
+			cond:    `req_host_in("a.com)`,
+			wantErr: "string literal not terminated",
+		},
+		{
+			name:    "missing parenthesis",
+			cond:    `(default_t() && req_cip_trusted()`,
+			wantErr: "expected )",
+		},
+		{
+			name:    "trailing tokens",
+			cond:    `default_t() req_cip_trusted()`,
+			wantErr: `unexpected "req_cip_trusted"`,
+		},
+		{
+			name:    "single ampersand",
+			cond:    `default_t() & req_cip_trusted()`,
+			wantErr: "unexpected &",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCondition(tt.cond)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateCondition(%q) error = %v, want nil", tt.cond, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateCondition(%q) error = %v, want error containing %q", tt.cond, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRouterCondition(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name: "not set",
+		},
+		{
+			name:        "valid",
+			annotations: map[string]string{GetAnnotationWithPrefix(RouterConditionKey): "req_cip_trusted()"},
+			want:        "req_cip_trusted()",
+		},
+		{
+			name:        "invalid",
+			annotations: map[string]string{GetAnnotationWithPrefix(RouterConditionKey): "req_cip_trusted("},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ing := &networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations},
+			}
+			got, err := ParseRouterCondition(ing)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRouterCondition() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRouterCondition() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
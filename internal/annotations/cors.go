@@ -0,0 +1,170 @@
+package annotations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// EnableCORSKey enables CORS of the Ingress
+	EnableCORSKey = "enable-cors"
+	// CORSAllowOriginKey is the comma separated origins allowed, "*" allows
+	// any origin
+	CORSAllowOriginKey = "cors-allow-origin"
+	// CORSAllowMethodsKey is the comma separated methods allowed
+	CORSAllowMethodsKey = "cors-allow-methods"
+	// CORSAllowHeadersKey is the comma separated request headers allowed
+	CORSAllowHeadersKey = "cors-allow-headers"
+	// CORSAllowCredentialsKey allows requests with credentials
+	CORSAllowCredentialsKey = "cors-allow-credentials"
+	// CORSMaxAgeKey is the seconds preflight response is cached by browsers
+	CORSMaxAgeKey = "cors-max-age"
+
+	// CORSAnyOrigin allows any origin
+	CORSAnyOrigin = "*"
+)
+
+var (
+	defCORSAllowOrigins = []string{CORSAnyOrigin}
+	defCORSAllowMethods = []string{"GET", "PUT", "POST", "DELETE", "PATCH", "OPTIONS"}
+	defCORSAllowHeaders = []string{
+		"DNT", "Keep-Alive", "User-Agent", "X-Requested-With", "If-Modified-Since",
+		"Cache-Control", "Content-Type", "Range", "Authorization",
+	}
+	defCORSMaxAge = 1728000
+
+	corsMethods = map[string]bool{
+		http.MethodGet:     true,
+		http.MethodHead:    true,
+		http.MethodPost:    true,
+		http.MethodPut:     true,
+		http.MethodPatch:   true,
+		http.MethodDelete:  true,
+		http.MethodConnect: true,
+		http.MethodOptions: true,
+		http.MethodTrace:   true,
+	}
+)
+
+func init() {
+	register(Parser{
+		Name: "cors",
+		Keys: []string{
+			EnableCORSKey, CORSAllowOriginKey, CORSAllowMethodsKey,
+			CORSAllowHeadersKey, CORSAllowCredentialsKey, CORSMaxAgeKey,
+		},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.CORS, err = ParseCORS(ing)
+			return err
+		},
+	})
+}
+
+// CORS is the CORS policy of an Ingress
+type CORS struct {
+	Enabled          bool
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	AllowCredentials bool
+	// MaxAge is in seconds
+	MaxAge int
+}
+
+// ParseCORS parses CORS annotations of ingress, unset annotations take
+// default values. Annotations are validated even if CORS is not enabled.
+func ParseCORS(ing *networking.Ingress) (CORS, error) {
+	c := CORS{
+		AllowOrigins: defCORSAllowOrigins,
+		AllowMethods: defCORSAllowMethods,
+		AllowHeaders: defCORSAllowHeaders,
+		MaxAge:       defCORSMaxAge,
+	}
+	var err error
+
+	if c.Enabled, err = GetBoolAnnotation(EnableCORSKey, ing); err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+
+	origins, err := GetCSVAnnotation(CORSAllowOriginKey, ing)
+	if err == nil {
+		if err := validateCORSOrigins(origins); err != nil {
+			return c, fmt.Errorf("the annotation %v %v", GetAnnotationWithPrefix(CORSAllowOriginKey), err)
+		}
+		c.AllowOrigins = origins
+	} else if err != ErrMissingAnnotations {
+		return c, err
+	}
+
+	methods, err := GetCSVAnnotation(CORSAllowMethodsKey, ing)
+	if err == nil {
+		for i, method := range methods {
+			methods[i] = strings.ToUpper(method)
+			if !corsMethods[methods[i]] {
+				return c, fmt.Errorf("the annotation %v contains an invalid method (%v)", GetAnnotationWithPrefix(CORSAllowMethodsKey), method)
+			}
+		}
+		c.AllowMethods = methods
+	} else if err != ErrMissingAnnotations {
+		return c, err
+	}
+
+	headers, err := GetCSVAnnotation(CORSAllowHeadersKey, ing)
+	if err == nil {
+		for _, header := range headers {
+			if !isToken(header) {
+				return c, fmt.Errorf("the annotation %v contains an invalid header (%v)", GetAnnotationWithPrefix(CORSAllowHeadersKey), header)
+			}
+		}
+		c.AllowHeaders = headers
+	} else if err != ErrMissingAnnotations {
+		return c, err
+	}
+
+	if c.AllowCredentials, err = GetBoolAnnotation(CORSAllowCredentialsKey, ing); err != nil && err != ErrMissingAnnotations {
+		return c, err
+	}
+	// browsers reject credentialed response allowing any origin
+	if c.AllowCredentials && c.AllowOrigins[0] == CORSAnyOrigin {
+		return c, fmt.Errorf("the annotation %v requires explicit origins in %v",
+			GetAnnotationWithPrefix(CORSAllowCredentialsKey), GetAnnotationWithPrefix(CORSAllowOriginKey))
+	}
+
+	maxAge, err := GetIntAnnotation(CORSMaxAgeKey, ing)
+	if err == nil {
+		if maxAge < 0 {
+			return c, fmt.Errorf("the annotation %v must not be negative (%v)", GetAnnotationWithPrefix(CORSMaxAgeKey), maxAge)
+		}
+		c.MaxAge = maxAge
+	} else if err != ErrMissingAnnotations {
+		return c, err
+	}
+
+	return c, nil
+}
+
+// validateCORSOrigins checks origins are either "*" alone or http and https
+// origins like https://example.com:8443
+func validateCORSOrigins(origins []string) error {
+	if len(origins) == 0 {
+		return fmt.Errorf("contains no origin")
+	}
+	for _, origin := range origins {
+		if origin == CORSAnyOrigin {
+			if len(origins) > 1 {
+				return fmt.Errorf("must not mix %v with other origins", CORSAnyOrigin)
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("contains an invalid origin (%v)", origin)
+		}
+	}
+	return nil
+}
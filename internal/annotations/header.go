@@ -0,0 +1,157 @@
+package annotations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// RequestHeadersSetKey is a JSON object of request headers to set
+	RequestHeadersSetKey = "request-headers-set"
+	// RequestHeadersAddKey is a JSON object of request headers to add
+	RequestHeadersAddKey = "request-headers-add"
+	// RequestHeadersRemoveKey is a list of request headers to remove
+	RequestHeadersRemoveKey = "request-headers-remove"
+	// ResponseHeadersSetKey is a JSON object of response headers to set
+	ResponseHeadersSetKey = "response-headers-set"
+	// ResponseHeadersAddKey is a JSON object of response headers to add
+	ResponseHeadersAddKey = "response-headers-add"
+	// ResponseHeadersRemoveKey is a list of response headers to remove
+	ResponseHeadersRemoveKey = "response-headers-remove"
+)
+
+// headerVariables are BFE variables allowed as header value
+var headerVariables = map[string]bool{
+	"%bfe_client_ip":             true,
+	"%bfe_client_port":           true,
+	"%bfe_request_host":          true,
+	"%bfe_session_id":            true,
+	"%bfe_log_id":                true,
+	"%bfe_cip":                   true,
+	"%bfe_vip":                   true,
+	"%bfe_server_name":           true,
+	"%bfe_cluster":               true,
+	"%bfe_backend_info":          true,
+	"%bfe_ssl_resume":            true,
+	"%bfe_ssl_cipher":            true,
+	"%bfe_ssl_version":           true,
+	"%bfe_ssl_ja3_raw":           true,
+	"%bfe_ssl_ja3_hash":          true,
+	"%bfe_protocol":              true,
+	"%client_cert_serial_number": true,
+}
+
+func init() {
+	register(Parser{
+		Name: "header",
+		Keys: []string{
+			RequestHeadersSetKey, RequestHeadersAddKey, RequestHeadersRemoveKey,
+			ResponseHeadersSetKey, ResponseHeadersAddKey, ResponseHeadersRemoveKey,
+		},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.Header, err = ParseHeader(ing)
+			return err
+		},
+	})
+}
+
+// HeaderValue is a header and its value
+type HeaderValue struct {
+	Name  string
+	Value string
+}
+
+// Header is the header manipulation of an Ingress, headers are sorted by name
+type Header struct {
+	RequestSet     []HeaderValue
+	RequestAdd     []HeaderValue
+	RequestRemove  []string
+	ResponseSet    []HeaderValue
+	ResponseAdd    []HeaderValue
+	ResponseRemove []string
+}
+
+// IsEmpty returns true if no header is manipulated
+func (h Header) IsEmpty() bool {
+	return len(h.RequestSet) == 0 && len(h.RequestAdd) == 0 && len(h.RequestRemove) == 0 &&
+		len(h.ResponseSet) == 0 && len(h.ResponseAdd) == 0 && len(h.ResponseRemove) == 0
+}
+
+// ParseHeader parses header annotations of ingress. A value starting with
+// "%" is a BFE variable, e.g. %bfe_client_ip or %bfe_log_id.
+func ParseHeader(ing *networking.Ingress) (Header, error) {
+	var h Header
+	var err error
+
+	if h.RequestSet, err = parseHeaderValues(RequestHeadersSetKey, ing); err != nil {
+		return h, err
+	}
+	if h.RequestAdd, err = parseHeaderValues(RequestHeadersAddKey, ing); err != nil {
+		return h, err
+	}
+	if h.RequestRemove, err = parseHeaderNames(RequestHeadersRemoveKey, ing); err != nil {
+		return h, err
+	}
+	if h.ResponseSet, err = parseHeaderValues(ResponseHeadersSetKey, ing); err != nil {
+		return h, err
+	}
+	if h.ResponseAdd, err = parseHeaderValues(ResponseHeadersAddKey, ing); err != nil {
+		return h, err
+	}
+	if h.ResponseRemove, err = parseHeaderNames(ResponseHeadersRemoveKey, ing); err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// parseHeaderValues parses annotation of JSON object mapping header to value
+func parseHeaderValues(name string, ing *networking.Ingress) ([]HeaderValue, error) {
+	var headers map[string]string
+	err := GetJSONAnnotation(name, ing, &headers)
+	if err == ErrMissingAnnotations {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]HeaderValue, 0, len(headers))
+	for header, value := range headers {
+		if !isToken(header) {
+			return nil, fmt.Errorf("the annotation %v contains an invalid header (%v)", GetAnnotationWithPrefix(name), header)
+		}
+		if strings.HasPrefix(value, "%") && !headerVariables[value] {
+			return nil, fmt.Errorf("the annotation %v contains an unknown variable (%v)", GetAnnotationWithPrefix(name), value)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("the annotation %v contains an invalid value of header %v", GetAnnotationWithPrefix(name), header)
+		}
+		values = append(values, HeaderValue{Name: header, Value: value})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Name < values[j].Name
+	})
+	return values, nil
+}
+
+// parseHeaderNames parses annotation of comma separated headers
+func parseHeaderNames(name string, ing *networking.Ingress) ([]string, error) {
+	headers, err := GetCSVAnnotation(name, ing)
+	if err == ErrMissingAnnotations {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, header := range headers {
+		if !isToken(header) {
+			return nil, fmt.Errorf("the annotation %v contains an invalid header (%v)", GetAnnotationWithPrefix(name), header)
+		}
+	}
+	sort.Strings(headers)
+	return headers, nil
+}
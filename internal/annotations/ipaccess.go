@@ -0,0 +1,48 @@
+package annotations
+
+import (
+	"net"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// AllowlistSourceRangeKey is the comma separated CIDRs allowed to access
+	// the Ingress, others are blocked
+	AllowlistSourceRangeKey = "allowlist-source-range"
+	// DenylistSourceRangeKey is the comma separated CIDRs blocked
+	DenylistSourceRangeKey = "denylist-source-range"
+)
+
+func init() {
+	register(Parser{
+		Name: "ipaccess",
+		Keys: []string{AllowlistSourceRangeKey, DenylistSourceRangeKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.IPAccess, err = ParseIPAccess(ing)
+			return err
+		},
+	})
+}
+
+// IPAccess is the client ip access control of an Ingress
+type IPAccess struct {
+	Allowlist []*net.IPNet
+	Denylist  []*net.IPNet
+}
+
+// ParseIPAccess parses allowlist and denylist annotations of ingress
+func ParseIPAccess(ing *networking.Ingress) (IPAccess, error) {
+	var a IPAccess
+	var err error
+
+	a.Allowlist, err = GetCIDRAnnotation(AllowlistSourceRangeKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return a, err
+	}
+	a.Denylist, err = GetCIDRAnnotation(DenylistSourceRangeKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return a, err
+	}
+	return a, nil
+}
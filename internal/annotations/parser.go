@@ -1,9 +1,13 @@
 package annotations
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
+	"time"
 
 	networking "k8s.io/api/networking/v1beta1"
 )
@@ -36,6 +40,82 @@ func (a ingAnnotations) parseString(name string) (string, error) {
 	return "", ErrMissingAnnotations
 }
 
+func (a ingAnnotations) parseBool(name string) (bool, error) {
+	val, ok := a[name]
+	if ok {
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return false, fmt.Errorf("the annotation %v does not contain a valid boolean value (%v)", name, val)
+		}
+		return b, nil
+	}
+	return false, ErrMissingAnnotations
+}
+
+func (a ingAnnotations) parseInt(name string) (int, error) {
+	val, ok := a[name]
+	if ok {
+		i, err := strconv.Atoi(val)
+		if err != nil {
+			return 0, fmt.Errorf("the annotation %v does not contain a valid integer value (%v)", name, val)
+		}
+		return i, nil
+	}
+	return 0, ErrMissingAnnotations
+}
+
+func (a ingAnnotations) parseDuration(name string) (time.Duration, error) {
+	val, ok := a[name]
+	if ok {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, fmt.Errorf("the annotation %v does not contain a valid duration (%v)", name, val)
+		}
+		return d, nil
+	}
+	return 0, ErrMissingAnnotations
+}
+
+func (a ingAnnotations) parseCSV(name string) ([]string, error) {
+	val, ok := a[name]
+	if ok {
+		var items []string
+		for _, item := range strings.Split(val, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("the annotation %v does not contain a valid value (%v)", name, val)
+		}
+		return items, nil
+	}
+	return nil, ErrMissingAnnotations
+}
+
+func (a ingAnnotations) parseCIDR(name string) ([]*net.IPNet, error) {
+	if _, err := a.parseCSV(name); err != nil {
+		return nil, err
+	}
+	nets, err := ParseCIDRs(a[name])
+	if err != nil {
+		return nil, fmt.Errorf("the annotation %v contains an invalid CIDR: %v", name, err)
+	}
+	return nets, nil
+}
+
+func (a ingAnnotations) parseJSON(name string, v interface{}) error {
+	val, ok := a[name]
+	if ok {
+		if err := json.Unmarshal([]byte(val), v); err != nil {
+			return fmt.Errorf("the annotation %v does not contain valid JSON: %v", name, err)
+		}
+		return nil
+	}
+	return ErrMissingAnnotations
+}
+
 func normalizeString(input string) string {
 	trimmedContent := []string{}
 	for _, line := range strings.Split(input, "\n") {
@@ -56,6 +136,100 @@ func GetStringAnnotation(name string, ing *networking.Ingress) (string, error) {
 	return ingAnnotations(ing.GetAnnotations()).parseString(v)
 }
 
+// GetBoolAnnotation extracts a boolean from an Ingress annotation
+func GetBoolAnnotation(name string, ing *networking.Ingress) (bool, error) {
+	v := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(v, ing)
+	if err != nil {
+		return false, err
+	}
+
+	return ingAnnotations(ing.GetAnnotations()).parseBool(v)
+}
+
+// GetIntAnnotation extracts an int from an Ingress annotation
+func GetIntAnnotation(name string, ing *networking.Ingress) (int, error) {
+	v := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(v, ing)
+	if err != nil {
+		return 0, err
+	}
+
+	return ingAnnotations(ing.GetAnnotations()).parseInt(v)
+}
+
+// GetDurationAnnotation extracts a duration like "1m30s" from an Ingress annotation
+func GetDurationAnnotation(name string, ing *networking.Ingress) (time.Duration, error) {
+	v := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(v, ing)
+	if err != nil {
+		return 0, err
+	}
+
+	return ingAnnotations(ing.GetAnnotations()).parseDuration(v)
+}
+
+// GetCSVAnnotation extracts a list of comma separated values from an
+// Ingress annotation, empty values are dropped
+func GetCSVAnnotation(name string, ing *networking.Ingress) ([]string, error) {
+	v := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(v, ing)
+	if err != nil {
+		return nil, err
+	}
+
+	return ingAnnotations(ing.GetAnnotations()).parseCSV(v)
+}
+
+// GetCIDRAnnotation extracts a list of comma separated CIDRs or IPs from an
+// Ingress annotation
+func GetCIDRAnnotation(name string, ing *networking.Ingress) ([]*net.IPNet, error) {
+	v := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(v, ing)
+	if err != nil {
+		return nil, err
+	}
+
+	return ingAnnotations(ing.GetAnnotations()).parseCIDR(v)
+}
+
+// GetJSONAnnotation unmarshals JSON of an Ingress annotation into v
+func GetJSONAnnotation(name string, ing *networking.Ingress, v interface{}) error {
+	n := GetAnnotationWithPrefix(name)
+	err := checkAnnotation(n, ing)
+	if err != nil {
+		return err
+	}
+
+	return ingAnnotations(ing.GetAnnotations()).parseJSON(n, v)
+}
+
+// ParseCIDRs parses comma separated CIDRs or IPs, a single IP is a network
+// of itself
+func ParseCIDRs(val string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, item := range strings.Split(val, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		cidr := item
+		if !strings.Contains(cidr, "/") {
+			if ip := net.ParseIP(cidr); ip != nil && ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %v", item)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
 // GetAnnotationWithPrefix returns the prefix of ingress annotations
 func GetAnnotationWithPrefix(suffix string) string {
 	return fmt.Sprintf("%v/%v", AnnotationsPrefix, suffix)
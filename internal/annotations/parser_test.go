@@ -0,0 +1,224 @@
+package annotations
+
+import (
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// buildIngress returns an Ingress with given annotations, names of
+// annotations are without prefix
+func buildIngress(annotations map[string]string) *networking.Ingress {
+	ing := &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "default",
+		},
+	}
+	if annotations != nil {
+		ing.Annotations = make(map[string]string)
+		for name, value := range annotations {
+			ing.Annotations[GetAnnotationWithPrefix(name)] = value
+		}
+	}
+	return ing
+}
+
+func TestGetAnnotationWithPrefix(t *testing.T) {
+	if got, want := GetAnnotationWithPrefix("canary"), "bfe.ingress.kubernetes.io/canary"; got != want {
+		t.Errorf("GetAnnotationWithPrefix() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckAnnotation(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		ing     *networking.Ingress
+		wantErr error
+	}{
+		{
+			name:    "nil ingress",
+			key:     "foo",
+			wantErr: ErrMissingAnnotations,
+		},
+		{
+			name:    "no annotations",
+			key:     "foo",
+			ing:     buildIngress(nil),
+			wantErr: ErrMissingAnnotations,
+		},
+		{
+			name:    "empty name",
+			ing:     buildIngress(map[string]string{"foo": "bar"}),
+			wantErr: ErrInvalidAnnotationName,
+		},
+		{
+			name: "valid",
+			key:  "foo",
+			ing:  buildIngress(map[string]string{"foo": "bar"}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkAnnotation(tt.key, tt.ing); err != tt.wantErr {
+				t.Errorf("checkAnnotation() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetStringAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+		wantErr     bool
+	}{
+		{
+			name:        "trimmed lines",
+			annotations: map[string]string{"foo": "  a \n b  "},
+			want:        "a\nb",
+		},
+		{
+			name:        "blank",
+			annotations: map[string]string{"foo": "  "},
+			wantErr:     true,
+		},
+		{
+			name:        "missing",
+			annotations: map[string]string{"bar": "a"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetStringAnnotation("foo", buildIngress(tt.annotations))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetStringAnnotation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetStringAnnotation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// annotation without prefix is not read
+	ing := buildIngress(nil)
+	ing.Annotations = map[string]string{"foo": "a"}
+	if _, err := GetStringAnnotation("foo", ing); err != ErrMissingAnnotations {
+		t.Errorf("GetStringAnnotation() of annotation without prefix error = %v, want %v", err, ErrMissingAnnotations)
+	}
+}
+
+func TestGetBoolAnnotation(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{value: "true", want: true},
+		{value: "false", want: false},
+		{value: "1", want: true},
+		{value: "yes", wantErr: true},
+		{value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := GetBoolAnnotation("foo", buildIngress(map[string]string{"foo": tt.value}))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetBoolAnnotation(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("GetBoolAnnotation(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := GetBoolAnnotation("foo", buildIngress(map[string]string{"bar": "true"})); err != ErrMissingAnnotations {
+		t.Errorf("GetBoolAnnotation() of missing annotation error = %v, want %v", err, ErrMissingAnnotations)
+	}
+}
+
+func TestGetIntAnnotation(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "10", want: 10},
+		{value: "-1", want: -1},
+		{value: "1.5", wantErr: true},
+		{value: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := GetIntAnnotation("foo", buildIngress(map[string]string{"foo": tt.value}))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetIntAnnotation(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("GetIntAnnotation(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := GetIntAnnotation("foo", buildIngress(nil)); err != ErrMissingAnnotations {
+		t.Errorf("GetIntAnnotation() of missing annotation error = %v, want %v", err, ErrMissingAnnotations)
+	}
+}
+
+func TestGetCSVAnnotation(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "a", want: []string{"a"}},
+		{value: " a, b ,,c ", want: []string{"a", "b", "c"}},
+		{value: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := GetCSVAnnotation("foo", buildIngress(map[string]string{"foo": tt.value}))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("GetCSVAnnotation(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetCSVAnnotation(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "10.0.0.0/8, 192.168.1.1", want: []string{"10.0.0.0/8", "192.168.1.1/32"}},
+		{value: "2001:db8::1", want: []string{"2001:db8::1/128"}},
+		{value: "10.0.0.0/33", wantErr: true},
+		{value: "foo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		nets, err := ParseCIDRs(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCIDRs(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, n := range nets {
+			got = append(got, n.String())
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCIDRs(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
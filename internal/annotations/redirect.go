@@ -0,0 +1,128 @@
+package annotations
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// SSLRedirectKey redirects http requests to https, it is on by default
+	// for hosts covered by spec.tls
+	SSLRedirectKey = "ssl-redirect"
+	// SSLRedirectCodeKey is the status code of ssl redirect, 308 or 301.
+	// 308 keeps the method and body of requests.
+	SSLRedirectCodeKey = "ssl-redirect-code"
+	// PermanentRedirectKey is the url all requests are redirected to
+	PermanentRedirectKey = "permanent-redirect"
+	// PermanentRedirectCodeKey is the status code of permanent redirect,
+	// 301 or 308
+	PermanentRedirectCodeKey = "permanent-redirect-code"
+	// TemporalRedirectKey is the url all requests are redirected to
+	TemporalRedirectKey = "temporal-redirect"
+	// TemporalRedirectCodeKey is the status code of temporal redirect, 302,
+	// 303 or 307
+	TemporalRedirectCodeKey = "temporal-redirect-code"
+
+	// DefSSLRedirectCode is the status code of ssl redirect if not set
+	DefSSLRedirectCode = http.StatusPermanentRedirect
+)
+
+var (
+	permanentRedirectCodes = []int{http.StatusMovedPermanently, http.StatusPermanentRedirect}
+	temporalRedirectCodes  = []int{http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect}
+)
+
+func init() {
+	register(Parser{
+		Name: "redirect",
+		Keys: []string{SSLRedirectKey, SSLRedirectCodeKey, PermanentRedirectKey, PermanentRedirectCodeKey, TemporalRedirectKey, TemporalRedirectCodeKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.Redirect, err = ParseRedirect(ing)
+			return err
+		},
+	})
+}
+
+// Redirect is the redirect config of an Ingress
+type Redirect struct {
+	// SSLRedirect is nil if not set
+	SSLRedirect     *bool
+	SSLRedirectCode int
+	Permanent       string
+	PermanentCode   int
+	Temporal        string
+	TemporalCode    int
+}
+
+// ParseRedirect parses redirect annotations of ingress
+func ParseRedirect(ing *networking.Ingress) (Redirect, error) {
+	r := Redirect{
+		SSLRedirectCode: DefSSLRedirectCode,
+		PermanentCode:   http.StatusMovedPermanently,
+		TemporalCode:    http.StatusFound,
+	}
+
+	sslRedirect, err := GetBoolAnnotation(SSLRedirectKey, ing)
+	if err == nil {
+		r.SSLRedirect = &sslRedirect
+	} else if err != ErrMissingAnnotations {
+		return r, err
+	}
+
+	if r.SSLRedirectCode, err = parseRedirectCode(SSLRedirectCodeKey, ing, r.SSLRedirectCode, permanentRedirectCodes); err != nil {
+		return r, err
+	}
+	if r.Permanent, err = parseRedirectURL(PermanentRedirectKey, ing); err != nil {
+		return r, err
+	}
+	if r.PermanentCode, err = parseRedirectCode(PermanentRedirectCodeKey, ing, r.PermanentCode, permanentRedirectCodes); err != nil {
+		return r, err
+	}
+	if r.Temporal, err = parseRedirectURL(TemporalRedirectKey, ing); err != nil {
+		return r, err
+	}
+	if r.TemporalCode, err = parseRedirectCode(TemporalRedirectCodeKey, ing, r.TemporalCode, temporalRedirectCodes); err != nil {
+		return r, err
+	}
+
+	return r, nil
+}
+
+// parseRedirectURL returns redirect url of annotation, it must be an
+// absolute http or https url
+func parseRedirectURL(name string, ing *networking.Ingress) (string, error) {
+	val, err := GetStringAnnotation(name, ing)
+	if err == ErrMissingAnnotations {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(val)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("the annotation %v does not contain a valid http or https url (%v)", GetAnnotationWithPrefix(name), val)
+	}
+	return val, nil
+}
+
+// parseRedirectCode returns status code of annotation, def if not set
+func parseRedirectCode(name string, ing *networking.Ingress, def int, allowed []int) (int, error) {
+	code, err := GetIntAnnotation(name, ing)
+	if err == ErrMissingAnnotations {
+		return def, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range allowed {
+		if c == code {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("the annotation %v must be one of %v (%v)", GetAnnotationWithPrefix(name), allowed, code)
+}
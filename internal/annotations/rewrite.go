@@ -0,0 +1,86 @@
+package annotations
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// RewriteStripPrefixKey strips path of Ingress rule from request path
+	RewriteStripPrefixKey = "rewrite.strip-prefix"
+	// RewriteAddPrefixKey adds a prefix to request path
+	RewriteAddPrefixKey = "rewrite.add-prefix"
+	// RewritePathRegexKey is the regular expression of request path replaced
+	// by RewritePathReplacementKey
+	RewritePathRegexKey = "rewrite.path-regex"
+	// RewritePathReplacementKey is the replacement of RewritePathRegexKey,
+	// $1 is the first submatch
+	RewritePathReplacementKey = "rewrite.path-replacement"
+)
+
+func init() {
+	register(Parser{
+		Name: "rewrite",
+		Keys: []string{RewriteStripPrefixKey, RewriteAddPrefixKey, RewritePathRegexKey, RewritePathReplacementKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.Rewrite, err = ParseRewrite(ing)
+			return err
+		},
+	})
+}
+
+// Rewrite is the path rewrite of an Ingress. Actions are applied in order
+// of strip prefix, regex replace and add prefix.
+type Rewrite struct {
+	StripPrefix     bool
+	PathRegex       string
+	PathReplacement string
+	AddPrefix       string
+}
+
+// IsEmpty returns true if no rewrite is defined
+func (r Rewrite) IsEmpty() bool {
+	return r == Rewrite{}
+}
+
+// ParseRewrite parses rewrite annotations of ingress
+func ParseRewrite(ing *networking.Ingress) (Rewrite, error) {
+	var r Rewrite
+	var err error
+
+	r.StripPrefix, err = GetBoolAnnotation(RewriteStripPrefixKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return r, err
+	}
+
+	r.AddPrefix, err = GetStringAnnotation(RewriteAddPrefixKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return r, err
+	}
+	if r.AddPrefix != "" && !strings.HasPrefix(r.AddPrefix, "/") {
+		return r, fmt.Errorf("the annotation %v must start with / (%v)", GetAnnotationWithPrefix(RewriteAddPrefixKey), r.AddPrefix)
+	}
+
+	r.PathRegex, err = GetStringAnnotation(RewritePathRegexKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return r, err
+	}
+	r.PathReplacement, err = GetStringAnnotation(RewritePathReplacementKey, ing)
+	if err != nil && err != ErrMissingAnnotations {
+		return r, err
+	}
+	if (r.PathRegex == "") != (r.PathReplacement == "") {
+		return r, fmt.Errorf("the annotations %v and %v must be set together",
+			GetAnnotationWithPrefix(RewritePathRegexKey), GetAnnotationWithPrefix(RewritePathReplacementKey))
+	}
+	if r.PathRegex != "" {
+		if _, err := regexp.Compile(r.PathRegex); err != nil {
+			return r, fmt.Errorf("the annotation %v contains an invalid regular expression: %v", GetAnnotationWithPrefix(RewritePathRegexKey), err)
+		}
+	}
+
+	return r, nil
+}
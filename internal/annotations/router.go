@@ -0,0 +1,103 @@
+package annotations
+
+import (
+	"fmt"
+	"strings"
+
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+const (
+	// RouterHeaderKey matches requests by header, e.g. "X-Env: staging"
+	RouterHeaderKey = "router.header"
+	// RouterCookieKey matches requests by cookie, e.g. "env: staging"
+	RouterCookieKey = "router.cookie"
+	// RouterQueryKey matches requests by query parameter, e.g. "env: staging"
+	RouterQueryKey = "router.query"
+)
+
+func init() {
+	register(Parser{
+		Name: "router",
+		Keys: []string{RouterHeaderKey, RouterCookieKey, RouterQueryKey},
+		Parse: func(ing *networking.Ingress, a *Annotations) (err error) {
+			a.Router, err = ParseRouter(ing)
+			return err
+		},
+	})
+}
+
+// Match is a key and value a request must carry
+type Match struct {
+	Key   string
+	Value string
+}
+
+// Router is the extra predicates of rules of an Ingress, nil if not set
+type Router struct {
+	Header *Match
+	Cookie *Match
+	Query  *Match
+}
+
+// ParseRouter parses router annotations of ingress
+func ParseRouter(ing *networking.Ingress) (Router, error) {
+	var r Router
+	var err error
+
+	if r.Header, err = parseMatch(RouterHeaderKey, ing); err != nil {
+		return r, err
+	}
+	if r.Cookie, err = parseMatch(RouterCookieKey, ing); err != nil {
+		return r, err
+	}
+	if r.Query, err = parseMatch(RouterQueryKey, ing); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// parseMatch parses annotation of "key: value" format, key must be a token
+// defined by RFC 7230. Value must not contain "|", which separates values
+// in BFE conditions.
+func parseMatch(name string, ing *networking.Ingress) (*Match, error) {
+	val, err := GetStringAnnotation(name, ing)
+	if err == ErrMissingAnnotations {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(val, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("the annotation %v is not of format \"key: value\" (%v)", GetAnnotationWithPrefix(name), val)
+	}
+	m := &Match{
+		Key:   strings.TrimSpace(parts[0]),
+		Value: strings.TrimSpace(parts[1]),
+	}
+	if !isToken(m.Key) {
+		return nil, fmt.Errorf("the annotation %v contains an invalid key (%v)", GetAnnotationWithPrefix(name), m.Key)
+	}
+	if m.Value == "" {
+		return nil, fmt.Errorf("the annotation %v contains an empty value", GetAnnotationWithPrefix(name))
+	}
+	if strings.Contains(m.Value, "|") {
+		return nil, fmt.Errorf("the annotation %v contains \"|\" in value (%v)", GetAnnotationWithPrefix(name), m.Value)
+	}
+	return m, nil
+}
+
+// isToken returns true if s is a token defined by RFC 7230
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c > 0x7e || c <= ' ' || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", c) {
+			return false
+		}
+	}
+	return true
+}
@@ -22,21 +22,23 @@ type Exec interface {
 
 //Command store context around a given bfe executable path
 type Command struct {
-	Binary string
-	Cmd    *exec.Cmd
+	Binary   string
+	ConfPath string
+	Cmd      *exec.Cmd
 }
 
 //NewCommand return a new Command from given bfe binary path
 func NewCommand() *Command {
 	return &Command{
-		Binary: defBfeBinary,
+		Binary:   defBfeBinary,
+		ConfPath: defBfeCfgPath,
 	}
 }
 
 //ExecCommand instanciates an exec.Cmd object to all nginx program
 func (bc *Command) ExecCommand(args ...string) *exec.Cmd {
 	cmdArgs := []string{}
-	cmdArgs = append(cmdArgs, "-c", defBfeCfgPath)
+	cmdArgs = append(cmdArgs, "-c", bc.ConfPath)
 	cmdArgs = append(cmdArgs, args...)
 	bc.Cmd = exec.Command(bc.Binary, cmdArgs...)
 	return bc.Cmd
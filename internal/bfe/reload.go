@@ -0,0 +1,141 @@
+package bfe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	defMonitorAddr   = "http://127.0.0.1:8421"
+	defReloadTimeout = 10 * time.Second
+)
+
+// reload targets of bfe monitor reload API, config of a bfe module is
+// reloaded by module name, e.g. mod_rewrite, or by module and table name if
+// the module loads several files, e.g. mod_block.product_rule_table
+const (
+	// ReloadServerDataConf reloads host, route and cluster config
+	ReloadServerDataConf = "server_data_conf"
+	// ReloadGslbDataConf reloads cluster table and gslb config
+	ReloadGslbDataConf = "gslb_data_conf"
+	// ReloadTLSConf reloads certificates and tls rules
+	ReloadTLSConf = "tls_conf"
+	// ReloadModRewrite reloads rules of mod_rewrite
+	ReloadModRewrite = "mod_rewrite"
+	// ReloadModRedirect reloads rules of mod_redirect
+	ReloadModRedirect = "mod_redirect"
+	// ReloadModHeader reloads rules of mod_header
+	ReloadModHeader = "mod_header"
+	// ReloadModAuthBasic reloads rules and user files of mod_auth_basic
+	ReloadModAuthBasic = "mod_auth_basic"
+	// ReloadModBlockProductRule reloads product rules of mod_block
+	ReloadModBlockProductRule = "mod_block.product_rule_table"
+	// ReloadModBlockGlobalIP reloads global ip blocklist of mod_block
+	ReloadModBlockGlobalIP = "mod_block.global_ip_table"
+	// ReloadModCORS reloads rules of mod_cors
+	ReloadModCORS = "mod_cors"
+)
+
+//ReloadResult is the result of reloading a config target
+type ReloadResult struct {
+	Target string
+	Err    error
+}
+
+//Reloader reloads config of a running bfe through its monitor reload API
+type Reloader struct {
+	// Addr is the base url of bfe monitor server
+	Addr    string
+	Client  *http.Client
+	Backoff wait.Backoff
+}
+
+//NewReloader return a new Reloader of local bfe monitor server
+func NewReloader() *Reloader {
+	return &Reloader{
+		Addr: defMonitorAddr,
+		Client: &http.Client{
+			Timeout: defReloadTimeout,
+		},
+		Backoff: wait.Backoff{
+			Steps:    5,
+			Duration: 500 * time.Millisecond,
+			Factor:   2,
+			Jitter:   0.1,
+		},
+	}
+}
+
+//Reload reloads targets one by one in given order, each target is retried
+//with backoff. Result of each target is returned, error is not nil if any
+//target failed.
+func (r *Reloader) Reload(targets ...string) ([]ReloadResult, error) {
+	results := make([]ReloadResult, 0, len(targets))
+	var failed []string
+
+	for _, target := range targets {
+		err := r.reloadWithRetry(target)
+		if err != nil {
+			klog.Warningf("reload bfe %v failed: %v", target, err)
+			failed = append(failed, target)
+		} else {
+			klog.V(3).Infof("reload bfe %v success", target)
+		}
+		results = append(results, ReloadResult{
+			Target: target,
+			Err:    err,
+		})
+	}
+
+	if len(failed) > 0 {
+		return results, fmt.Errorf("reload bfe failed: %v", strings.Join(failed, ","))
+	}
+	return results, nil
+}
+
+func (r *Reloader) reloadWithRetry(target string) error {
+	var lastErr error
+	err := wait.ExponentialBackoff(r.Backoff, func() (bool, error) {
+		lastErr = r.reload(target)
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		return lastErr
+	}
+	return nil
+}
+
+// reloadResponse is the body returned by bfe reload API
+type reloadResponse struct {
+	Error *string `json:"error"`
+}
+
+func (r *Reloader) reload(target string) error {
+	url := fmt.Sprintf("%s/reload/%s", strings.TrimSuffix(r.Addr, "/"), target)
+	resp, err := r.Client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response error: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var res reloadResponse
+	if err := json.Unmarshal(body, &res); err == nil && res.Error != nil && *res.Error != "" {
+		return fmt.Errorf("%s", *res.Error)
+	}
+	return nil
+}
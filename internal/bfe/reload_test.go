@@ -0,0 +1,155 @@
+package bfe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// fakeMonitor is a bfe monitor server which fails reload of each target for
+// given times before it succeeds
+type fakeMonitor struct {
+	lock     sync.Mutex
+	failures map[string]int
+	calls    map[string]int
+	// status is returned for failed reloads
+	status int
+	// body is returned for failed reloads with status 200
+	body string
+}
+
+func (m *fakeMonitor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	target := strings.TrimPrefix(req.URL.Path, "/reload/")
+
+	m.lock.Lock()
+	m.calls[target]++
+	failed := m.failures[target] < 0 || m.calls[target] <= m.failures[target]
+	m.lock.Unlock()
+
+	if !failed {
+		fmt.Fprint(w, `{"error":null}`)
+		return
+	}
+	w.WriteHeader(m.status)
+	fmt.Fprint(w, m.body)
+}
+
+func newTestReloader(addr string, steps int) *Reloader {
+	return &Reloader{
+		Addr:   addr,
+		Client: &http.Client{Timeout: time.Second},
+		Backoff: wait.Backoff{
+			Steps:    steps,
+			Duration: time.Millisecond,
+			Factor:   1,
+		},
+	}
+}
+
+func TestReload(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures map[string]int
+		status   int
+		body     string
+		steps    int
+		wantErr  map[string]string
+		// wantCalls is the number of requests of each target
+		wantCalls map[string]int
+	}{
+		{
+			name:      "success",
+			steps:     3,
+			wantCalls: map[string]int{ReloadServerDataConf: 1, ReloadTLSConf: 1},
+		},
+		{
+			name:      "retry until success",
+			failures:  map[string]int{ReloadServerDataConf: 2},
+			status:    http.StatusInternalServerError,
+			body:      "busy",
+			steps:     3,
+			wantCalls: map[string]int{ReloadServerDataConf: 3, ReloadTLSConf: 1},
+		},
+		{
+			name:      "non-200 status exhausts retries",
+			failures:  map[string]int{ReloadServerDataConf: -1},
+			status:    http.StatusNotFound,
+			body:      "not found\n",
+			steps:     3,
+			wantErr:   map[string]string{ReloadServerDataConf: "status 404: not found"},
+			wantCalls: map[string]int{ReloadServerDataConf: 3, ReloadTLSConf: 1},
+		},
+		{
+			name:      "error in response body",
+			failures:  map[string]int{ReloadTLSConf: -1},
+			status:    http.StatusOK,
+			body:      `{"error":"load cert error"}`,
+			steps:     2,
+			wantErr:   map[string]string{ReloadTLSConf: "load cert error"},
+			wantCalls: map[string]int{ReloadServerDataConf: 1, ReloadTLSConf: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := tt.failures
+			if failures == nil {
+				failures = make(map[string]int)
+			}
+			monitor := &fakeMonitor{
+				failures: failures,
+				calls:    make(map[string]int),
+				status:   tt.status,
+				body:     tt.body,
+			}
+			server := httptest.NewServer(monitor)
+			defer server.Close()
+
+			r := newTestReloader(server.URL+"/", tt.steps)
+			results, err := r.Reload(ReloadServerDataConf, ReloadTLSConf)
+			if (err != nil) != (len(tt.wantErr) > 0) {
+				t.Fatalf("Reload() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if len(results) != 2 || results[0].Target != ReloadServerDataConf || results[1].Target != ReloadTLSConf {
+				t.Fatalf("Reload() results = %v, want results of targets in order", results)
+			}
+			for _, result := range results {
+				want := tt.wantErr[result.Target]
+				if want == "" && result.Err != nil {
+					t.Errorf("target %v: unexpected error %v", result.Target, result.Err)
+				}
+				if want != "" && (result.Err == nil || result.Err.Error() != want) {
+					t.Errorf("target %v: error = %v, want %v", result.Target, result.Err, want)
+				}
+			}
+
+			for target, want := range tt.wantCalls {
+				if got := monitor.calls[target]; got != want {
+					t.Errorf("target %v: %d requests, want %d", target, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReloadUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	addr := server.URL
+	server.Close()
+
+	r := newTestReloader(addr, 2)
+	results, err := r.Reload(ReloadModRewrite)
+	if err == nil {
+		t.Fatalf("Reload() of closed server succeeded")
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Errorf("Reload() results = %v, want error of %v", results, ReloadModRewrite)
+	}
+}
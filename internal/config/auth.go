@@ -0,0 +1,45 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// AuthBasicRule authenticates requests matching Cond by users in UserFile
+type AuthBasicRule struct {
+	Cond string
+	// UserFile is the htpasswd file relative to BFE conf directory
+	UserFile string
+	Realm    string
+}
+
+// AuthBasicConf is the content of mod_auth_basic/auth_basic_rule.data
+type AuthBasicConf struct {
+	Version string
+	// Config maps product to ordered rules
+	Config map[string][]AuthBasicRule
+
+	// userFiles maps path of htpasswd file to its content
+	userFiles map[string][]byte
+}
+
+// NewAuthBasicConf returns an empty AuthBasicConf
+func NewAuthBasicConf() *AuthBasicConf {
+	return &AuthBasicConf{
+		Config:    make(map[string][]AuthBasicRule),
+		userFiles: make(map[string][]byte),
+	}
+}
+
+// AddRule appends a rule to product
+func (a *AuthBasicConf) AddRule(product string, rule AuthBasicRule) {
+	a.Config[product] = append(a.Config[product], rule)
+}
+
+// AddUserFile adds htpasswd file of secret namespace/name, path of the file
+// relative to BFE conf directory is returned
+func (a *AuthBasicConf) AddUserFile(secretKey string, data []byte) string {
+	name := path.Join(AuthBasicUserDir, strings.Replace(secretKey, "/", "-", -1)+".htpasswd")
+	a.userFiles[name] = data
+	return name
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+)
+
+// actions of mod_block
+const (
+	// BlockClose closes the connection
+	BlockClose = "CLOSE"
+)
+
+// BlockAction is the action of block rule
+type BlockAction struct {
+	Cmd    string
+	Params []string
+}
+
+// BlockRule blocks requests matching Cond
+type BlockRule struct {
+	Cond   string
+	Name   string
+	Action BlockAction
+}
+
+// BlockConf is the content of mod_block/block_rules.data
+type BlockConf struct {
+	Version string
+	// Config maps product to rules
+	Config map[string][]BlockRule
+
+	// ipBlocklist are networks blocked when connections are accepted,
+	// rendered into mod_block/ip_blocklist.data
+	ipBlocklist []*net.IPNet
+}
+
+// NewBlockConf returns an empty BlockConf
+func NewBlockConf() *BlockConf {
+	return &BlockConf{
+		Config: make(map[string][]BlockRule),
+	}
+}
+
+// AddRule appends a rule closing connections of requests matching cond
+func (b *BlockConf) AddRule(product, name, cond string) {
+	b.Config[product] = append(b.Config[product], BlockRule{
+		Cond: cond,
+		Name: name,
+		Action: BlockAction{
+			Cmd:    BlockClose,
+			Params: []string{},
+		},
+	})
+}
+
+// AddIPBlocklist adds networks to the global ip blocklist, which is checked
+// against the peer address of connections
+func (b *BlockConf) AddIPBlocklist(nets ...*net.IPNet) {
+	b.ipBlocklist = append(b.ipBlocklist, nets...)
+}
+
+// renderIPBlocklist returns content of ip_blocklist.data, an address range
+// "start end" in each line
+func (b *BlockConf) renderIPBlocklist() []byte {
+	var buf bytes.Buffer
+	for _, ipNet := range b.ipBlocklist {
+		start, end := IPRange(ipNet)
+		fmt.Fprintf(&buf, "%s %s\n", start, end)
+	}
+	return buf.Bytes()
+}
+
+// IPRange returns the first and last address of network
+func IPRange(ipNet *net.IPNet) (net.IP, net.IP) {
+	ip := ipNet.IP
+	if ip4 := ip.To4(); ip4 != nil && len(ipNet.Mask) == net.IPv4len {
+		ip = ip4
+	}
+	start := make(net.IP, len(ip))
+	end := make(net.IP, len(ip))
+	for i := range ip {
+		start[i] = ip[i] & ipNet.Mask[i]
+		end[i] = ip[i] | ^ipNet.Mask[i]
+	}
+	return start, end
+}
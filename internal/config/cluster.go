@@ -0,0 +1,176 @@
+package config
+
+import (
+	"sort"
+)
+
+const (
+	// GslbBlackhole is the sub cluster name reserved by BFE to drop traffic
+	GslbBlackhole = "GSLB_BLACKHOLE"
+
+	// default timeouts of cluster in milliseconds
+	defTimeoutConnSrv         = 2000
+	defTimeoutResponseHeader  = 60000
+	defTimeoutReadClient      = 30000
+	defTimeoutWriteClient     = 60000
+	defTimeoutReadClientAgain = 60000
+
+	defRetryMax      = 2
+	defCheckFailNum  = 5
+	defCheckInterval = 1000
+)
+
+// BackendBasic is the BackendConf section of a cluster
+type BackendBasic struct {
+	TimeoutConnSrv        int
+	TimeoutResponseHeader int
+	MaxIdleConnsPerHost   int
+	RetryLevel            int
+}
+
+// CheckBasic is the CheckConf section of a cluster
+type CheckBasic struct {
+	Schem         string
+	FailNum       int
+	CheckInterval int
+}
+
+// HashBasic is the HashConf section of GslbBasic
+type HashBasic struct {
+	HashStrategy  int
+	SessionSticky bool
+}
+
+// GslbBasic is the GslbBasic section of a cluster
+type GslbBasic struct {
+	CrossRetry int
+	RetryMax   int
+	HashConf   HashBasic
+}
+
+// ClusterBasic is the ClusterBasic section of a cluster
+type ClusterBasic struct {
+	TimeoutReadClient      int
+	TimeoutWriteClient     int
+	TimeoutReadClientAgain int
+}
+
+// Cluster is the config of a BFE cluster
+type Cluster struct {
+	BackendConf  BackendBasic
+	CheckConf    CheckBasic
+	GslbBasic    GslbBasic
+	ClusterBasic ClusterBasic
+}
+
+// ClusterConf is the content of server_data_conf/cluster_conf.data
+type ClusterConf struct {
+	Version string
+	Config  map[string]Cluster
+}
+
+// Backend is an instance of sub cluster
+type Backend struct {
+	Name   string
+	Addr   string
+	Port   int
+	Weight int
+}
+
+// ClusterTableConf is the content of cluster_conf/cluster_table.data
+type ClusterTableConf struct {
+	Version string
+	// Config maps cluster to sub clusters, sub cluster to backends
+	Config map[string]map[string][]Backend
+}
+
+// GslbConf is the content of cluster_conf/gslb.data
+type GslbConf struct {
+	Hostname string
+	Ts       string
+	// Clusters maps cluster to weight of each sub cluster
+	Clusters map[string]map[string]int
+}
+
+// NewClusterConf returns an empty ClusterConf
+func NewClusterConf() *ClusterConf {
+	return &ClusterConf{
+		Config: make(map[string]Cluster),
+	}
+}
+
+// NewClusterTableConf returns an empty ClusterTableConf
+func NewClusterTableConf() *ClusterTableConf {
+	return &ClusterTableConf{
+		Config: make(map[string]map[string][]Backend),
+	}
+}
+
+// NewGslbConf returns an empty GslbConf
+func NewGslbConf() *GslbConf {
+	return &GslbConf{
+		Clusters: make(map[string]map[string]int),
+	}
+}
+
+// NewCluster returns cluster config with default values
+func NewCluster() Cluster {
+	return Cluster{
+		BackendConf: BackendBasic{
+			TimeoutConnSrv:        defTimeoutConnSrv,
+			TimeoutResponseHeader: defTimeoutResponseHeader,
+		},
+		CheckConf: CheckBasic{
+			Schem:         "tcp",
+			FailNum:       defCheckFailNum,
+			CheckInterval: defCheckInterval,
+		},
+		GslbBasic: GslbBasic{
+			RetryMax: defRetryMax,
+		},
+		ClusterBasic: ClusterBasic{
+			TimeoutReadClient:      defTimeoutReadClient,
+			TimeoutWriteClient:     defTimeoutWriteClient,
+			TimeoutReadClientAgain: defTimeoutReadClientAgain,
+		},
+	}
+}
+
+// AddCluster adds a cluster with a single sub cluster of the same name.
+// An empty backends creates an empty cluster.
+func (c *Config) AddCluster(name string, backends []Backend) {
+	c.AddWeightedCluster(name, map[string][]Backend{name: backends}, map[string]int{name: 100})
+}
+
+// AddWeightedCluster adds a cluster whose traffic is split between sub
+// clusters by weights, weights should sum to 100
+func (c *Config) AddWeightedCluster(name string, subClusters map[string][]Backend, weights map[string]int) {
+	table := make(map[string][]Backend, len(subClusters))
+	for subCluster, backends := range subClusters {
+		table[subCluster] = sortBackends(backends)
+	}
+
+	gslb := map[string]int{
+		GslbBlackhole: 0,
+	}
+	for subCluster, weight := range weights {
+		gslb[subCluster] = weight
+	}
+
+	c.Cluster.Config[name] = NewCluster()
+	c.ClusterTable.Config[name] = table
+	c.Gslb.Clusters[name] = gslb
+}
+
+// sortBackends returns a sorted copy of backends, never nil
+func sortBackends(backends []Backend) []Backend {
+	sorted := make([]Backend, len(backends))
+	copy(sorted, backends)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Addr != sorted[j].Addr {
+			return sorted[i].Addr < sorted[j].Addr
+		}
+		return sorted[i].Port < sorted[j].Port
+	})
+	return sorted
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// CondPathIn returns condition matching request path exactly
+func CondPathIn(path string) string {
+	return "req_path_in(" + strconv.Quote(path) + ", false)"
+}
+
+// CondPathPrefixIn returns condition matching request path prefix
+func CondPathPrefixIn(path string) string {
+	return "req_path_prefix_in(" + strconv.Quote(path) + ", false)"
+}
+
+// CondPathElementPrefixIn returns condition matching request path prefix
+// element by element, e.g. /foo matches /foo and /foo/bar but not /foobar
+func CondPathElementPrefixIn(path string) string {
+	return "req_path_element_prefix_in(" + strconv.Quote(path) + ", false)"
+}
+
+// CondPathRegMatch returns condition matching request path by regular expression
+func CondPathRegMatch(pattern string) string {
+	return "req_path_regmatch(" + strconv.Quote(pattern) + ")"
+}
+
+// CondHeaderValueIn returns condition matching value of request header
+func CondHeaderValueIn(header, value string) string {
+	return "req_header_value_in(" + strconv.Quote(header) + ", " + strconv.Quote(value) + ", false)"
+}
+
+// CondCookieValueIn returns condition matching value of request cookie
+func CondCookieValueIn(name, value string) string {
+	return "req_cookie_value_in(" + strconv.Quote(name) + ", " + strconv.Quote(value) + ", false)"
+}
+
+// CondQueryValueIn returns condition matching value of request query parameter
+func CondQueryValueIn(key, value string) string {
+	return "req_query_value_in(" + strconv.Quote(key) + ", " + strconv.Quote(value) + ", false)"
+}
+
+// CondProtoSecure is the BFE condition matching requests over https
+const CondProtoSecure = "req_proto_secure()"
+
+// CondCORSPreflight is the BFE condition matching CORS preflight requests
+const CondCORSPreflight = `req_method_in("OPTIONS") && req_header_key_in("Origin") && req_header_key_in("Access-Control-Request-Method")`
+
+// CondClientIPIn returns condition matching client address in any of nets.
+// If forwarded is true, the real client address derived by BFE, e.g. from
+// trusted X-Forwarded-For, is matched instead of the peer address.
+func CondClientIPIn(nets []*net.IPNet, forwarded bool) string {
+	primitive := "ses_sip_range"
+	if forwarded {
+		primitive = "req_cip_range"
+	}
+
+	conds := make([]string, 0, len(nets))
+	for _, ipNet := range nets {
+		start, end := IPRange(ipNet)
+		conds = append(conds, primitive+"("+strconv.Quote(start.String())+", "+strconv.Quote(end.String())+")")
+	}
+	return "(" + strings.Join(conds, " || ") + ")"
+}
+
+// CondNot returns condition matching requests not matching cond
+func CondNot(cond string) string {
+	return "!(" + cond + ")"
+}
+
+// CondAnd returns condition matching all of conds, CondDefault is omitted
+func CondAnd(conds ...string) string {
+	var parts []string
+	for _, cond := range conds {
+		if cond != CondDefault {
+			parts = append(parts, cond)
+		}
+	}
+	if len(parts) == 0 {
+		return CondDefault
+	}
+	return strings.Join(parts, " && ")
+}
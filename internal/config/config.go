@@ -4,15 +4,87 @@ import "time"
 
 // Configuration contains all the settings required by an Ingress controller
 type Configuration struct {
-	Namespace   string
-	ResycPeriod time.Duration
+	Namespace      string
+	KubeConfigFile string
+	ResycPeriod    time.Duration
+	// MaxConfigVersions is the number of retained BFE config versions
+	MaxConfigVersions int
+	// DryRun renders BFE config without starting or reloading BFE
+	DryRun bool
+	// DryRunDir is the directory rendered config is written to in dry run
+	// mode, a diff is printed to stdout if it is empty
+	DryRunDir string
+	// DefaultBackendService is namespace/name of the service serving
+	// requests not matching any rule, a built-in backend answering 404 is
+	// used if it is empty
+	DefaultBackendService string
+	// DefaultBackendPort is the port of the built-in default backend
+	DefaultBackendPort int
+	// ExternalNameResolvePeriod is the interval of re-resolving hosts of
+	// ExternalName services
+	ExternalNameResolvePeriod time.Duration
+	// ValidationWebhook is the address of validating admission webhook
+	// server, the webhook is disabled if it is empty
+	ValidationWebhook         string
+	ValidationWebhookCertPath string
+	ValidationWebhookKeyPath  string
+	// ConfigMap is namespace/name of the ConfigMap of global settings
+	ConfigMap string
+	// MetricsAddr is the address of metrics and debug endpoints, they are
+	// disabled if it is empty
+	MetricsAddr string
 }
 
 // Config contains BFE config
 type Config struct {
-	//Namespace string
+	// Version is written into every generated data file
+	Version string
+
+	HostRule     *HostRuleConf
+	RouteRule    *RouteRuleConf
+	Cluster      *ClusterConf
+	ClusterTable *ClusterTableConf
+	Gslb         *GslbConf
+	ServerCert   *ServerCertConf
+	TLSRule      *TLSRuleConf
+	Rewrite      *RewriteConf
+	Redirect     *RedirectConf
+	Header       *HeaderConf
+	AuthBasic    *AuthBasicConf
+	Block        *BlockConf
+	CORS         *CORSConf
+
+	// Sources maps product, cluster or certificate name to keys of
+	// ingresses it is generated from
+	Sources map[string][]string
 }
 
+//NewConfig return a empty BFE config
 func NewConfig() *Config {
-	return &Config{}
+	return &Config{
+		HostRule:     NewHostRuleConf(),
+		RouteRule:    NewRouteRuleConf(),
+		Cluster:      NewClusterConf(),
+		ClusterTable: NewClusterTableConf(),
+		Gslb:         NewGslbConf(),
+		ServerCert:   NewServerCertConf(),
+		TLSRule:      NewTLSRuleConf(),
+		Rewrite:      NewRewriteConf(),
+		Redirect:     NewRedirectConf(),
+		Header:       NewHeaderConf(),
+		AuthBasic:    NewAuthBasicConf(),
+		Block:        NewBlockConf(),
+		CORS:         NewCORSConf(),
+		Sources:      make(map[string][]string),
+	}
+}
+
+// AddSource records ingress key as a source of product, cluster or certificate name
+func (c *Config) AddSource(name, ingKey string) {
+	for _, key := range c.Sources[name] {
+		if key == ingKey {
+			return
+		}
+	}
+	c.Sources[name] = append(c.Sources[name], ingKey)
 }
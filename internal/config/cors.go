@@ -0,0 +1,32 @@
+package config
+
+// CORSRule adds CORS headers to responses of requests matching Cond, and
+// answers preflight requests matching Cond without forwarding them
+type CORSRule struct {
+	Cond                          string
+	AccessControlAllowOrigins     []string
+	AccessControlAllowCredentials bool
+	AccessControlAllowMethods     []string
+	AccessControlAllowHeaders     []string
+	// AccessControlMaxAge is in seconds
+	AccessControlMaxAge int
+}
+
+// CORSConf is the content of mod_cors/cors_rule.data
+type CORSConf struct {
+	Version string
+	// Config maps product to ordered rules
+	Config map[string][]CORSRule
+}
+
+// NewCORSConf returns an empty CORSConf
+func NewCORSConf() *CORSConf {
+	return &CORSConf{
+		Config: make(map[string][]CORSRule),
+	}
+}
+
+// AddRule appends a rule to product
+func (c *CORSConf) AddRule(product string, rule CORSRule) {
+	c.Config[product] = append(c.Config[product], rule)
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// diffContext is the number of unchanged lines around each change
+	diffContext = 3
+)
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Diff returns unified diff from old rendered files to cur rendered files
+func Diff(old, cur map[string][]byte) string {
+	names := make([]string, 0, len(old)+len(cur))
+	for name := range old {
+		names = append(names, name)
+	}
+	for name := range cur {
+		if _, ok := old[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		a, inOld := old[name]
+		b, inCur := cur[name]
+		if inOld && inCur && bytes.Equal(a, b) {
+			continue
+		}
+
+		from, to := "a/"+name, "b/"+name
+		if !inOld {
+			from = "/dev/null"
+		}
+		if !inCur {
+			to = "/dev/null"
+		}
+		fmt.Fprintf(&buf, "--- %s\n+++ %s\n", from, to)
+		writeHunks(&buf, diffLines(splitLines(a), splitLines(b)))
+	}
+	return buf.String()
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// diffLines returns edit script from a to b. Common prefix and suffix are
+// trimmed before computing the longest common subsequence of the rest.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of LCS of ma[i:] and mb[j:]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i]})
+			i++
+			j++
+		case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', ma[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// writeHunks writes changes of ops as unified diff hunks
+func writeHunks(buf *bytes.Buffer, ops []diffOp) {
+	// aLine and bLine are line numbers of ops[k] in a and b
+	aLines := make([]int, len(ops)+1)
+	bLines := make([]int, len(ops)+1)
+	for k, op := range ops {
+		aLines[k+1], bLines[k+1] = aLines[k], bLines[k]
+		if op.kind != '+' {
+			aLines[k+1]++
+		}
+		if op.kind != '-' {
+			bLines[k+1]++
+		}
+	}
+
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+
+		// extend hunk until diffContext*2 unchanged lines are found
+		start := k - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end, same := k, 0
+		for end < len(ops) && same <= diffContext*2 {
+			if ops[end].kind == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+			end++
+		}
+		if same > diffContext {
+			end -= same - diffContext
+		}
+
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n",
+			hunkRange(aLines[start], aLines[end]), hunkRange(bLines[start], bLines[end]))
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(buf, "%c%s\n", op.kind, op.line)
+		}
+		k = end
+	}
+}
+
+func hunkRange(from, to int) string {
+	count := to - from
+	if count == 0 {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, count)
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"sort"
+	"strings"
+
+	"github.com/baidu/ingress-bfe/internal/bfe"
+)
+
+// sections are config files reloaded together by BFE, named by reload
+// target, in the order they are reloaded
+var sections = []string{
+	bfe.ReloadServerDataConf,
+	bfe.ReloadGslbDataConf,
+	bfe.ReloadTLSConf,
+	bfe.ReloadModRewrite,
+	bfe.ReloadModRedirect,
+	bfe.ReloadModHeader,
+	bfe.ReloadModAuthBasic,
+	bfe.ReloadModBlockProductRule,
+	bfe.ReloadModBlockGlobalIP,
+	bfe.ReloadModCORS,
+}
+
+// fileSections maps config file to the section it belongs to
+var fileSections = map[string]string{
+	HostRuleFile:     bfe.ReloadServerDataConf,
+	RouteRuleFile:    bfe.ReloadServerDataConf,
+	ClusterFile:      bfe.ReloadServerDataConf,
+	ClusterTableFile: bfe.ReloadGslbDataConf,
+	GslbFile:         bfe.ReloadGslbDataConf,
+	ServerCertFile:   bfe.ReloadTLSConf,
+	TLSRuleFile:      bfe.ReloadTLSConf,
+	RewriteFile:      bfe.ReloadModRewrite,
+	RedirectFile:     bfe.ReloadModRedirect,
+	HeaderFile:       bfe.ReloadModHeader,
+	AuthBasicFile:    bfe.ReloadModAuthBasic,
+	BlockFile:        bfe.ReloadModBlockProductRule,
+	IPBlocklistFile:  bfe.ReloadModBlockGlobalIP,
+	CORSFile:         bfe.ReloadModCORS,
+}
+
+// fileSection returns the section of config file, files under directory of
+// a module, e.g. htpasswd files, belong to the module
+func fileSection(name string) string {
+	if section, ok := fileSections[name]; ok {
+		return section
+	}
+	return strings.SplitN(name, "/", 2)[0]
+}
+
+// Sections returns all sections in reload order
+func Sections() []string {
+	return append([]string(nil), sections...)
+}
+
+// Hash returns the hash of each section. Version is excluded, so sections of
+// same content have same hash.
+func (c *Config) Hash() (map[string]string, error) {
+	version := c.Version
+	c.Version = ""
+	files, err := c.Render()
+	c.Version = version
+	if err != nil {
+		return nil, err
+	}
+
+	hashers := make(map[string]hash.Hash, len(sections))
+	for _, section := range sections {
+		hashers[section] = sha1.New()
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		h := hashers[fileSection(name)]
+		h.Write([]byte(name))
+		h.Write(files[name])
+	}
+
+	hashes := make(map[string]string, len(hashers))
+	for section, h := range hashers {
+		hashes[section] = hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
+
+// ChangedSections returns sections whose hash differs from old, in reload order
+func ChangedSections(old, cur map[string]string) []string {
+	var changed []string
+	for _, section := range sections {
+		if old[section] != cur[section] {
+			changed = append(changed, section)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,50 @@
+package config
+
+// actions of mod_header
+const (
+	HeaderReqSet = "REQ_HEADER_SET"
+	HeaderReqAdd = "REQ_HEADER_ADD"
+	HeaderReqDel = "REQ_HEADER_DEL"
+	HeaderRspSet = "RSP_HEADER_SET"
+	HeaderRspAdd = "RSP_HEADER_ADD"
+	HeaderRspDel = "RSP_HEADER_DEL"
+)
+
+// HeaderAction is an action of header rule, a value starting with "%" is
+// a BFE variable, e.g. %bfe_client_ip
+type HeaderAction struct {
+	Cmd    string   `json:"cmd"`
+	Params []string `json:"params"`
+}
+
+// HeaderRule modifies headers of requests matching Cond
+type HeaderRule struct {
+	Cond    string         `json:"cond"`
+	Actions []HeaderAction `json:"actions"`
+	// Last stops matching following rules
+	Last bool `json:"last"`
+}
+
+// HeaderConf is the content of mod_header/header_rule.data
+type HeaderConf struct {
+	Version string
+	// Config maps product to lists of ordered rules, BFE matches each list
+	// independently and Last only stops matching within its list
+	Config map[string][][]HeaderRule
+}
+
+// NewHeaderConf returns an empty HeaderConf
+func NewHeaderConf() *HeaderConf {
+	return &HeaderConf{
+		Config: make(map[string][][]HeaderRule),
+	}
+}
+
+// AddRule appends a rule to the rule list of product, all rules of a
+// product are in a single list
+func (h *HeaderConf) AddRule(product string, rule HeaderRule) {
+	if len(h.Config[product]) == 0 {
+		h.Config[product] = [][]HeaderRule{nil}
+	}
+	h.Config[product][0] = append(h.Config[product][0], rule)
+}
@@ -0,0 +1,39 @@
+package config
+
+// actions of mod_redirect
+const (
+	RedirectURLSet    = "URL_SET"
+	RedirectSchemeSet = "SCHEME_SET"
+)
+
+// RedirectAction is an action of redirect rule
+type RedirectAction struct {
+	Cmd    string
+	Params []string
+}
+
+// RedirectRule redirects requests matching Cond with status code Status
+type RedirectRule struct {
+	Cond    string
+	Actions []RedirectAction
+	Status  int
+}
+
+// RedirectConf is the content of mod_redirect/redirect.data
+type RedirectConf struct {
+	Version string
+	// Config maps product to ordered rules
+	Config map[string][]RedirectRule
+}
+
+// NewRedirectConf returns an empty RedirectConf
+func NewRedirectConf() *RedirectConf {
+	return &RedirectConf{
+		Config: make(map[string][]RedirectRule),
+	}
+}
+
+// AddRule appends a rule to product
+func (r *RedirectConf) AddRule(product string, rule RedirectRule) {
+	r.Config[product] = append(r.Config[product], rule)
+}
@@ -0,0 +1,42 @@
+package config
+
+// actions of mod_rewrite
+const (
+	RewritePathPrefixTrim = "PATH_PREFIX_TRIM"
+	RewritePathPrefixAdd  = "PATH_PREFIX_ADD"
+	RewritePathSet        = "PATH_SET"
+	RewritePathRegexSet   = "PATH_REGEX_SET"
+)
+
+// RewriteAction is an action of rewrite rule
+type RewriteAction struct {
+	Cmd    string
+	Params []string
+}
+
+// RewriteRule rewrites requests matching Cond
+type RewriteRule struct {
+	Cond    string
+	Actions []RewriteAction
+	// Last stops matching following rules
+	Last bool
+}
+
+// RewriteConf is the content of mod_rewrite/rewrite.data
+type RewriteConf struct {
+	Version string
+	// Config maps product to ordered rules
+	Config map[string][]RewriteRule
+}
+
+// NewRewriteConf returns an empty RewriteConf
+func NewRewriteConf() *RewriteConf {
+	return &RewriteConf{
+		Config: make(map[string][]RewriteRule),
+	}
+}
+
+// AddRule appends a rule to product
+func (r *RewriteConf) AddRule(product string, rule RewriteRule) {
+	r.Config[product] = append(r.Config[product], rule)
+}
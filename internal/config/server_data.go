@@ -0,0 +1,69 @@
+package config
+
+import (
+	"sort"
+)
+
+const (
+	// DefaultProduct is the product serving Ingress rules without host
+	DefaultProduct = "default"
+
+	// CondDefault is the BFE condition matching every request
+	CondDefault = "default_t()"
+)
+
+// HostRuleConf is the content of server_data_conf/host_rule.data
+type HostRuleConf struct {
+	Version        string
+	DefaultProduct string `json:",omitempty"`
+	// Hosts maps host tag to hosts
+	Hosts map[string][]string
+	// HostTags maps product to host tags
+	HostTags map[string][]string
+	// Vips maps product to vips
+	Vips map[string][]string
+}
+
+// NewHostRuleConf returns an empty HostRuleConf
+func NewHostRuleConf() *HostRuleConf {
+	return &HostRuleConf{
+		Hosts:    make(map[string][]string),
+		HostTags: make(map[string][]string),
+		Vips:     make(map[string][]string),
+	}
+}
+
+// AddHost binds host to product, a host tag is created for each host
+func (h *HostRuleConf) AddHost(product, host string) {
+	if _, ok := h.Hosts[host]; ok {
+		return
+	}
+	h.Hosts[host] = []string{host}
+	h.HostTags[product] = append(h.HostTags[product], host)
+	sort.Strings(h.HostTags[product])
+}
+
+// RouteRule is a forward rule of a product
+type RouteRule struct {
+	Cond        string
+	ClusterName string
+}
+
+// RouteRuleConf is the content of server_data_conf/route_rule.data
+type RouteRuleConf struct {
+	Version string
+	// ProductRule maps product to ordered rules, first matched rule wins
+	ProductRule map[string][]RouteRule
+}
+
+// NewRouteRuleConf returns an empty RouteRuleConf
+func NewRouteRuleConf() *RouteRuleConf {
+	return &RouteRuleConf{
+		ProductRule: make(map[string][]RouteRule),
+	}
+}
+
+// AddRule appends a rule to product
+func (r *RouteRuleConf) AddRule(product string, rule RouteRule) {
+	r.ProductRule[product] = append(r.ProductRule[product], rule)
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"path"
+	"sort"
+)
+
+const (
+	// defTLSGrade is the security grade of tls rules
+	defTLSGrade = "C"
+)
+
+var (
+	// defCipherSuites are cipher suites of tls rules, suites joined by "|" are of equal preference
+	defCipherSuites = []string{
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256|TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256",
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256|TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256",
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384",
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA",
+		"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA",
+		"TLS_RSA_WITH_AES_128_GCM_SHA256",
+		"TLS_RSA_WITH_AES_256_GCM_SHA384",
+		"TLS_RSA_WITH_AES_128_CBC_SHA",
+		"TLS_RSA_WITH_AES_256_CBC_SHA",
+	}
+	defCurvePreferences = []string{"CurveP256"}
+	defNextProtos       = []string{"http/1.1"}
+)
+
+// ServerCert is the certificate and key files of a certificate
+type ServerCert struct {
+	ServerCertFile   string
+	ServerKeyFile    string
+	OcspResponseFile string `json:",omitempty"`
+}
+
+// ServerCerts is the Config section of server_cert_conf.data
+type ServerCerts struct {
+	// Default is the name of certificate used when no certificate matches SNI
+	Default string
+	// CertConf maps certificate name to files
+	CertConf map[string]ServerCert
+}
+
+// ServerCertConf is the content of tls_conf/server_cert_conf.data
+type ServerCertConf struct {
+	Version string
+	Config  ServerCerts
+
+	// certFiles maps path of pem file to its content
+	certFiles map[string][]byte
+}
+
+// TLSRule is the tls rule of a product
+type TLSRule struct {
+	SniConf          []string
+	CipherSuites     []string
+	CurvePreferences []string
+	NextProtos       []string
+	Grade            string
+	ClientAuth       bool
+	ClientCAName     string
+}
+
+// TLSRuleConf is the content of tls_conf/tls_rule_conf.data
+type TLSRuleConf struct {
+	Version           string
+	DefaultNextProtos []string
+	// Config maps product to tls rule
+	Config map[string]*TLSRule
+}
+
+// NewServerCertConf returns an empty ServerCertConf
+func NewServerCertConf() *ServerCertConf {
+	return &ServerCertConf{
+		Config: ServerCerts{
+			CertConf: make(map[string]ServerCert),
+		},
+		certFiles: make(map[string][]byte),
+	}
+}
+
+// NewTLSRuleConf returns an empty TLSRuleConf
+func NewTLSRuleConf() *TLSRuleConf {
+	return &TLSRuleConf{
+		DefaultNextProtos: defNextProtos,
+		Config:            make(map[string]*TLSRule),
+	}
+}
+
+// AddCert adds a certificate, the first added certificate is the default
+// one. pem holds both certificate and key, it is written into a file of
+// each config version, so a version never refers to certificates it was
+// not tested with.
+func (s *ServerCertConf) AddCert(name string, pem []byte) {
+	if s.Config.Default == "" {
+		s.Config.Default = name
+	}
+	// relative path is relative to BFE conf directory
+	file := path.Join(ServerCertDir, name+".pem")
+	s.certFiles[file] = pem
+	s.Config.CertConf[name] = ServerCert{
+		ServerCertFile: file,
+		ServerKeyFile:  file,
+	}
+}
+
+// IsEmpty returns true if no certificate is added
+func (s *ServerCertConf) IsEmpty() bool {
+	return len(s.Config.CertConf) == 0
+}
+
+// AddHost adds host to SNI of product tls rule
+func (t *TLSRuleConf) AddHost(product, host string) {
+	rule, ok := t.Config[product]
+	if !ok {
+		rule = &TLSRule{
+			CipherSuites:     defCipherSuites,
+			CurvePreferences: defCurvePreferences,
+			NextProtos:       defNextProtos,
+			Grade:            defTLSGrade,
+		}
+		t.Config[product] = rule
+	}
+	for _, h := range rule.SniConf {
+		if h == host {
+			return
+		}
+	}
+	rule.SniConf = append(rule.SniConf, host)
+	sort.Strings(rule.SniConf)
+}
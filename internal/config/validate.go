@@ -0,0 +1,318 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// ValidationError is an error found in a config file
+type ValidationError struct {
+	// File is the path relative to BFE conf directory, empty if unknown
+	File string
+	// Name is the product, cluster or certificate the error belongs to, empty if unknown
+	Name string
+	Msg  string
+}
+
+func (e ValidationError) Error() string {
+	msg := e.Msg
+	if e.Name != "" {
+		msg = fmt.Sprintf("%s: %s", e.Name, msg)
+	}
+	if e.File != "" {
+		msg = fmt.Sprintf("%s: %s", e.File, msg)
+	}
+	return msg
+}
+
+// validator loads config files under root and collects errors
+type validator struct {
+	root string
+	errs []ValidationError
+}
+
+// Validate loads config files rendered under BFE conf directory root,
+// checks their format and references between them
+func Validate(root string) []ValidationError {
+	v := &validator{root: root}
+
+	hostRule := &HostRuleConf{}
+	routeRule := &RouteRuleConf{}
+	cluster := &ClusterConf{}
+	clusterTable := &ClusterTableConf{}
+	gslb := &GslbConf{}
+	if !v.load(HostRuleFile, hostRule) ||
+		!v.load(RouteRuleFile, routeRule) ||
+		!v.load(ClusterFile, cluster) ||
+		!v.load(ClusterTableFile, clusterTable) ||
+		!v.load(GslbFile, gslb) {
+		return v.errs
+	}
+
+	v.checkHostRule(hostRule)
+	v.checkRouteRule(routeRule, hostRule, cluster)
+	v.checkClusters(cluster, clusterTable, gslb)
+
+	if _, err := os.Stat(filepath.Join(root, ServerCertFile)); err == nil {
+		serverCert := &ServerCertConf{}
+		tlsRule := &TLSRuleConf{}
+		if v.load(ServerCertFile, serverCert) && v.load(TLSRuleFile, tlsRule) {
+			v.checkTLS(serverCert, tlsRule)
+		}
+	}
+
+	rewrite := &RewriteConf{}
+	if v.load(RewriteFile, rewrite) {
+		v.checkRewrite(rewrite, hostRule)
+	}
+	redirect := &RedirectConf{}
+	if v.load(RedirectFile, redirect) {
+		v.checkRedirect(redirect, hostRule)
+	}
+	header := &HeaderConf{}
+	if v.load(HeaderFile, header) {
+		v.checkHeader(header, hostRule)
+	}
+	authBasic := &AuthBasicConf{}
+	if v.load(AuthBasicFile, authBasic) {
+		v.checkAuthBasic(authBasic, hostRule)
+	}
+	block := &BlockConf{}
+	if v.load(BlockFile, block) {
+		v.checkBlock(block, hostRule)
+	}
+	cors := &CORSConf{}
+	if v.load(CORSFile, cors) {
+		v.checkCORS(cors, hostRule)
+	}
+
+	return v.errs
+}
+
+func (v *validator) addError(file, name, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{
+		File: file,
+		Name: name,
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) load(file string, conf interface{}) bool {
+	data, err := ioutil.ReadFile(filepath.Join(v.root, file))
+	if err != nil {
+		v.addError(file, "", "read error: %v", err)
+		return false
+	}
+	if err := json.Unmarshal(data, conf); err != nil {
+		v.addError(file, "", "json error: %v", err)
+		return false
+	}
+	return true
+}
+
+func (v *validator) checkHostRule(conf *HostRuleConf) {
+	owner := make(map[string]string)
+	for product, tags := range conf.HostTags {
+		for _, tag := range tags {
+			hosts, ok := conf.Hosts[tag]
+			if !ok {
+				v.addError(HostRuleFile, product, "host tag %v not found in Hosts", tag)
+				continue
+			}
+			for _, host := range hosts {
+				if p, ok := owner[host]; ok && p != product {
+					v.addError(HostRuleFile, product, "host %v already belongs to product %v", host, p)
+				}
+				owner[host] = product
+			}
+		}
+	}
+}
+
+func (v *validator) checkRouteRule(conf *RouteRuleConf, hostRule *HostRuleConf, cluster *ClusterConf) {
+	for product, rules := range conf.ProductRule {
+		if _, ok := hostRule.HostTags[product]; !ok && product != hostRule.DefaultProduct {
+			v.addError(RouteRuleFile, product, "product not found in %v", HostRuleFile)
+		}
+		for _, rule := range rules {
+			if rule.Cond == "" {
+				v.addError(RouteRuleFile, product, "empty condition")
+			}
+			if _, ok := cluster.Config[rule.ClusterName]; !ok {
+				v.addError(RouteRuleFile, product, "cluster %v not found in %v", rule.ClusterName, ClusterFile)
+			}
+		}
+	}
+}
+
+func (v *validator) checkClusters(conf *ClusterConf, table *ClusterTableConf, gslb *GslbConf) {
+	for name := range conf.Config {
+		subClusters, ok := table.Config[name]
+		if !ok {
+			v.addError(ClusterTableFile, name, "cluster not found")
+			continue
+		}
+		weights, ok := gslb.Clusters[name]
+		if !ok {
+			v.addError(GslbFile, name, "cluster not found")
+			continue
+		}
+
+		sum := 0
+		for subCluster, weight := range weights {
+			if weight < 0 {
+				v.addError(GslbFile, name, "negative weight of sub cluster %v", subCluster)
+			}
+			sum += weight
+			if _, ok := subClusters[subCluster]; !ok && subCluster != GslbBlackhole {
+				v.addError(GslbFile, name, "sub cluster %v not found in %v", subCluster, ClusterTableFile)
+			}
+		}
+		if sum != 100 {
+			v.addError(GslbFile, name, "sum of weights is %d, not 100", sum)
+		}
+
+		for subCluster, backends := range subClusters {
+			for _, backend := range backends {
+				if net.ParseIP(backend.Addr) == nil {
+					v.addError(ClusterTableFile, name, "invalid addr %v in sub cluster %v", backend.Addr, subCluster)
+				}
+				if backend.Port <= 0 || backend.Port > 65535 {
+					v.addError(ClusterTableFile, name, "invalid port %d in sub cluster %v", backend.Port, subCluster)
+				}
+				if backend.Weight < 0 {
+					v.addError(ClusterTableFile, name, "negative weight of %v in sub cluster %v", backend.Addr, subCluster)
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) checkTLS(conf *ServerCertConf, tlsRule *TLSRuleConf) {
+	if _, ok := conf.Config.CertConf[conf.Config.Default]; !ok {
+		v.addError(ServerCertFile, conf.Config.Default, "default certificate not found")
+	}
+	for name, cert := range conf.Config.CertConf {
+		for _, file := range []string{cert.ServerCertFile, cert.ServerKeyFile} {
+			// relative path is relative to BFE conf directory
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(v.root, file)
+			}
+			if _, err := os.Stat(file); err != nil {
+				v.addError(ServerCertFile, name, "%v", err)
+			}
+		}
+	}
+	for product, rule := range tlsRule.Config {
+		if len(rule.SniConf) == 0 {
+			v.addError(TLSRuleFile, product, "empty SniConf")
+		}
+	}
+}
+
+// checkProduct checks product of module rules exists
+func (v *validator) checkProduct(file, product string, hostRule *HostRuleConf) {
+	if _, ok := hostRule.HostTags[product]; !ok && product != hostRule.DefaultProduct {
+		v.addError(file, product, "product not found in %v", HostRuleFile)
+	}
+}
+
+func (v *validator) checkRewrite(conf *RewriteConf, hostRule *HostRuleConf) {
+	for product, rules := range conf.Config {
+		v.checkProduct(RewriteFile, product, hostRule)
+		for _, rule := range rules {
+			if rule.Cond == "" {
+				v.addError(RewriteFile, product, "empty condition")
+			}
+			if len(rule.Actions) == 0 {
+				v.addError(RewriteFile, product, "no action")
+			}
+		}
+	}
+}
+
+func (v *validator) checkRedirect(conf *RedirectConf, hostRule *HostRuleConf) {
+	for product, rules := range conf.Config {
+		v.checkProduct(RedirectFile, product, hostRule)
+		for _, rule := range rules {
+			if rule.Cond == "" {
+				v.addError(RedirectFile, product, "empty condition")
+			}
+			if len(rule.Actions) == 0 {
+				v.addError(RedirectFile, product, "no action")
+			}
+			if rule.Status < 300 || rule.Status > 399 {
+				v.addError(RedirectFile, product, "invalid status %d", rule.Status)
+			}
+		}
+	}
+}
+
+func (v *validator) checkHeader(conf *HeaderConf, hostRule *HostRuleConf) {
+	for product, ruleLists := range conf.Config {
+		v.checkProduct(HeaderFile, product, hostRule)
+		for _, rules := range ruleLists {
+			for _, rule := range rules {
+				if rule.Cond == "" {
+					v.addError(HeaderFile, product, "empty condition")
+				}
+				if len(rule.Actions) == 0 {
+					v.addError(HeaderFile, product, "no action")
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) checkAuthBasic(conf *AuthBasicConf, hostRule *HostRuleConf) {
+	for product, rules := range conf.Config {
+		v.checkProduct(AuthBasicFile, product, hostRule)
+		for _, rule := range rules {
+			if rule.Cond == "" {
+				v.addError(AuthBasicFile, product, "empty condition")
+			}
+			file := rule.UserFile
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(v.root, file)
+			}
+			if _, err := os.Stat(file); err != nil {
+				v.addError(AuthBasicFile, product, "%v", err)
+			}
+		}
+	}
+}
+
+func (v *validator) checkBlock(conf *BlockConf, hostRule *HostRuleConf) {
+	for product, rules := range conf.Config {
+		v.checkProduct(BlockFile, product, hostRule)
+		for _, rule := range rules {
+			if rule.Cond == "" {
+				v.addError(BlockFile, product, "empty condition")
+			}
+			if rule.Name == "" {
+				v.addError(BlockFile, product, "empty rule name")
+			}
+		}
+	}
+}
+
+func (v *validator) checkCORS(conf *CORSConf, hostRule *HostRuleConf) {
+	for product, rules := range conf.Config {
+		v.checkProduct(CORSFile, product, hostRule)
+		for _, rule := range rules {
+			if rule.Cond == "" {
+				v.addError(CORSFile, product, "empty condition")
+			}
+			if len(rule.AccessControlAllowOrigins) == 0 {
+				v.addError(CORSFile, product, "no allowed origin")
+			}
+			if rule.AccessControlMaxAge < 0 {
+				v.addError(CORSFile, product, "negative max age %d", rule.AccessControlMaxAge)
+			}
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	// VersionFormat is the time format of config versions, versions sort by time
+	VersionFormat = "20060102150405.000000"
+
+	// initVersion is the version of the BFE conf directory found at startup
+	initVersion = "00000000000000.000000"
+
+	// DefMaxVersions is the default number of retained versions
+	DefMaxVersions = 10
+)
+
+// Version is a retained version of BFE conf directory
+type Version struct {
+	Name    string
+	Path    string
+	ModTime time.Time
+	Current bool
+}
+
+// VersionStore keeps each rendered config in a versioned directory, and
+// switches BFE conf directory between versions by replacing a symlink.
+// Versions and the symlink are kept inside the conf directory shipped with
+// BFE, which may be a volume mount point and can not be renamed.
+type VersionStore struct {
+	// Root is the conf directory shipped with BFE, it is not modified and
+	// every version is created from a copy of it
+	Root string
+	// Link is the conf directory BFE runs with, a symlink to the current
+	// version
+	Link string
+	// Dir holds all versions
+	Dir string
+	// MaxVersions is the number of versions retained by Prune
+	MaxVersions int
+}
+
+// NewVersionStore returns a VersionStore of BFE conf directory root
+func NewVersionStore(root string, maxVersions int) *VersionStore {
+	if maxVersions <= 0 {
+		maxVersions = DefMaxVersions
+	}
+	return &VersionStore{
+		Root: root,
+		// config shipped with BFE refers to files by paths like
+		// ../conf/tls_conf/certs/server.crt relative to conf directory,
+		// they are kept valid by naming the link conf
+		Link:        filepath.Join(root, "conf"),
+		Dir:         filepath.Join(root, "versions"),
+		MaxVersions: maxVersions,
+	}
+}
+
+// NewVersion returns a version name of current time
+func NewVersion() string {
+	return time.Now().Format(VersionFormat)
+}
+
+// Init creates the initial version from Root and points Link to it, unless
+// Link is left by a previous run
+func (s *VersionStore) Init() error {
+	info, err := os.Lstat(s.Link)
+	if err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%v is not a symlink", s.Link)
+		}
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("stat %v error: %v", s.Link, err)
+	}
+
+	if err := os.MkdirAll(s.Dir, ReadWriteByUser); err != nil {
+		return fmt.Errorf("create directory %v error: %v", s.Dir, err)
+	}
+	if _, err := s.create(initVersion); err != nil {
+		return err
+	}
+	return s.Switch(initVersion)
+}
+
+// create copies Root into a new version, versions and Link are excluded
+func (s *VersionStore) create(version string) (string, error) {
+	path := s.path(version)
+	if err := os.RemoveAll(path); err != nil {
+		return "", fmt.Errorf("remove %v error: %v", path, err)
+	}
+	if err := copyDir(s.Root, path, s.Dir, s.Link); err != nil {
+		return "", fmt.Errorf("copy %v error: %v", s.Root, err)
+	}
+	return path, nil
+}
+
+func (s *VersionStore) path(version string) string {
+	return filepath.Join(s.Dir, version)
+}
+
+// Current returns the version BFE conf directory points to
+func (s *VersionStore) Current() (string, error) {
+	target, err := os.Readlink(s.Link)
+	if err != nil {
+		return "", fmt.Errorf("read link %v error: %v", s.Link, err)
+	}
+	return filepath.Base(target), nil
+}
+
+// Create copies Root into a new version, then writes rendered files into
+// it. Files rendered by previous versions but not by this one, e.g.
+// certificates no longer referenced, are therefore absent, and files shipped
+// with BFE are restored. Path of the new version is returned.
+func (s *VersionStore) Create(version string, files map[string][]byte) (string, error) {
+	current, err := s.Current()
+	if err != nil {
+		return "", err
+	}
+	if version == current {
+		return "", fmt.Errorf("version %v is in use", version)
+	}
+
+	path, err := s.create(version)
+	if err != nil {
+		return "", err
+	}
+	if err := WriteFiles(path, files); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Remove deletes a version which is not in use
+func (s *VersionStore) Remove(version string) error {
+	current, err := s.Current()
+	if err != nil {
+		return err
+	}
+	if version == current {
+		return fmt.Errorf("version %v is in use", version)
+	}
+	return os.RemoveAll(s.path(version))
+}
+
+// Switch points BFE conf directory to version atomically
+func (s *VersionStore) Switch(version string) error {
+	if _, err := os.Stat(s.path(version)); err != nil {
+		return fmt.Errorf("version %v not found: %v", version, err)
+	}
+
+	tmp := s.Link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(s.path(version), tmp); err != nil {
+		return fmt.Errorf("create symlink %v error: %v", tmp, err)
+	}
+	// rename replaces the old symlink atomically
+	if err := os.Rename(tmp, s.Link); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace symlink %v error: %v", s.Link, err)
+	}
+	return nil
+}
+
+// Rollback switches BFE conf directory to the newest version older than the
+// current one, the version switched to is returned
+func (s *VersionStore) Rollback() (string, error) {
+	versions, err := s.List()
+	if err != nil {
+		return "", err
+	}
+
+	for i, v := range versions {
+		if !v.Current {
+			continue
+		}
+		if i == 0 {
+			return "", fmt.Errorf("no version older than %v", v.Name)
+		}
+		prev := versions[i-1].Name
+		return prev, s.Switch(prev)
+	}
+	return "", fmt.Errorf("current version not found in %v", s.Dir)
+}
+
+// List returns retained versions from the oldest to the newest
+func (s *VersionStore) List() ([]Version, error) {
+	current, err := s.Current()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("read directory %v error: %v", s.Dir, err)
+	}
+
+	versions := make([]Version, 0, len(infos))
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		versions = append(versions, Version{
+			Name:    info.Name(),
+			Path:    s.path(info.Name()),
+			ModTime: info.ModTime(),
+			Current: info.Name() == current,
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Name < versions[j].Name
+	})
+	return versions, nil
+}
+
+// Prune removes the oldest versions until MaxVersions versions are left,
+// the current version is always retained
+func (s *VersionStore) Prune() error {
+	versions, err := s.List()
+	if err != nil {
+		return err
+	}
+
+	excess := len(versions) - s.MaxVersions
+	for _, v := range versions {
+		if excess <= 0 {
+			break
+		}
+		if v.Current {
+			continue
+		}
+		if err := os.RemoveAll(v.Path); err != nil {
+			return fmt.Errorf("remove version %v error: %v", v.Name, err)
+		}
+		excess--
+	}
+	return nil
+}
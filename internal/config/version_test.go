@@ -0,0 +1,97 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %v error: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestVersionStore(t *testing.T) {
+	root, err := ioutil.TempDir("", "bfe-conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	if err := WriteFiles(root, map[string][]byte{"bfe.conf": []byte("shipped")}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewVersionStore(root, 2)
+	if err := s.Init(); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	// root is kept as it is, bfe runs with the link
+	if info, err := os.Lstat(root); err != nil || !info.IsDir() {
+		t.Fatalf("%v is not a directory any more: %v", root, err)
+	}
+	if got := readFile(t, filepath.Join(s.Link, "bfe.conf")); got != "shipped" {
+		t.Errorf("bfe.conf of initial version is %q", got)
+	}
+	// a second Init keeps the current version
+	if err := s.Init(); err != nil {
+		t.Fatalf("second Init() error: %v", err)
+	}
+
+	create := func(version, pem string) {
+		t.Helper()
+		cfg := NewConfig()
+		cfg.Version = version
+		cfg.ServerCert.AddCert("default-tls", []byte(pem))
+		files, err := cfg.Render()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.Create(version, files); err != nil {
+			t.Fatalf("Create(%v) error: %v", version, err)
+		}
+		if err := s.Switch(version); err != nil {
+			t.Fatalf("Switch(%v) error: %v", version, err)
+		}
+	}
+	certFile := filepath.Join(s.Link, ServerCertDir, "default-tls.pem")
+
+	create("20200101000000.000000", "cert 1")
+	create("20200102000000.000000", "cert 2")
+	if got := readFile(t, certFile); got != "cert 2" {
+		t.Errorf("certificate of current version is %q, want cert 2", got)
+	}
+	if got := readFile(t, filepath.Join(s.Link, "bfe.conf")); got != "shipped" {
+		t.Errorf("bfe.conf of version is %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(s.Link, "versions")); !os.IsNotExist(err) {
+		t.Errorf("versions are copied into a version: %v", err)
+	}
+
+	version, err := s.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback() error: %v", err)
+	}
+	if version != "20200101000000.000000" {
+		t.Errorf("Rollback() = %v", version)
+	}
+	// the old version keeps the certificate it was created with
+	if got := readFile(t, certFile); got != "cert 1" {
+		t.Errorf("certificate after rollback is %q, want cert 1", got)
+	}
+
+	if err := s.Prune(); err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	versions, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || !versions[0].Current {
+		t.Errorf("List() after Prune() = %+v", versions)
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// HostRuleFile is the path of host_rule.data relative to BFE conf directory
+	HostRuleFile = "server_data_conf/host_rule.data"
+	// RouteRuleFile is the path of route_rule.data relative to BFE conf directory
+	RouteRuleFile = "server_data_conf/route_rule.data"
+	// ClusterFile is the path of cluster_conf.data relative to BFE conf directory
+	ClusterFile = "server_data_conf/cluster_conf.data"
+	// ClusterTableFile is the path of cluster_table.data relative to BFE conf directory
+	ClusterTableFile = "cluster_conf/cluster_table.data"
+	// GslbFile is the path of gslb.data relative to BFE conf directory
+	GslbFile = "cluster_conf/gslb.data"
+	// ServerCertFile is the path of server_cert_conf.data relative to BFE conf directory
+	ServerCertFile = "tls_conf/server_cert_conf.data"
+	// ServerCertDir is the directory of certificate files relative to BFE conf directory
+	ServerCertDir = "tls_conf/certs"
+	// TLSRuleFile is the path of tls_rule_conf.data relative to BFE conf directory
+	TLSRuleFile = "tls_conf/tls_rule_conf.data"
+	// RewriteFile is the path of mod_rewrite data relative to BFE conf directory
+	RewriteFile = "mod_rewrite/rewrite.data"
+	// RedirectFile is the path of mod_redirect data relative to BFE conf directory
+	RedirectFile = "mod_redirect/redirect.data"
+	// HeaderFile is the path of mod_header data relative to BFE conf directory
+	HeaderFile = "mod_header/header_rule.data"
+	// AuthBasicFile is the path of mod_auth_basic data relative to BFE conf directory
+	AuthBasicFile = "mod_auth_basic/auth_basic_rule.data"
+	// AuthBasicUserDir is the directory of htpasswd files relative to BFE conf directory
+	AuthBasicUserDir = "mod_auth_basic/users"
+	// BlockFile is the path of mod_block product rules relative to BFE conf directory
+	BlockFile = "mod_block/block_rules.data"
+	// IPBlocklistFile is the path of mod_block global ip blocklist relative to BFE conf directory
+	IPBlocklistFile = "mod_block/ip_blocklist.data"
+	// CORSFile is the path of mod_cors data relative to BFE conf directory
+	CORSFile = "mod_cors/cors_rule.data"
+
+	// ReadWriteByUser defines linux permission to read and write files for the owner user
+	ReadWriteByUser = 0700
+)
+
+// Render marshals every part of Config, the result maps file path
+// relative to BFE conf directory to file content
+func (c *Config) Render() (map[string][]byte, error) {
+	c.HostRule.Version = c.Version
+	c.RouteRule.Version = c.Version
+	c.Cluster.Version = c.Version
+	c.ClusterTable.Version = c.Version
+	c.Gslb.Ts = c.Version
+	c.ServerCert.Version = c.Version
+	c.TLSRule.Version = c.Version
+	c.Rewrite.Version = c.Version
+	c.Redirect.Version = c.Version
+	c.Header.Version = c.Version
+	c.AuthBasic.Version = c.Version
+	c.Block.Version = c.Version
+	c.CORS.Version = c.Version
+
+	contents := map[string]interface{}{
+		HostRuleFile:     c.HostRule,
+		RouteRuleFile:    c.RouteRule,
+		ClusterFile:      c.Cluster,
+		ClusterTableFile: c.ClusterTable,
+		GslbFile:         c.Gslb,
+		RewriteFile:      c.Rewrite,
+		RedirectFile:     c.Redirect,
+		HeaderFile:       c.Header,
+		AuthBasicFile:    c.AuthBasic,
+		BlockFile:        c.Block,
+		CORSFile:         c.CORS,
+	}
+	// BFE requires a default certificate, tls_conf shipped with BFE is
+	// used unless any certificate is referenced by ingresses
+	if !c.ServerCert.IsEmpty() {
+		contents[ServerCertFile] = c.ServerCert
+		contents[TLSRuleFile] = c.TLSRule
+	}
+
+	files := make(map[string][]byte, len(contents))
+	for name, content := range contents {
+		data, err := json.MarshalIndent(content, "", "    ")
+		if err != nil {
+			return nil, fmt.Errorf("marshal %v error: %v", name, err)
+		}
+		files[name] = data
+	}
+	for name, data := range c.AuthBasic.userFiles {
+		files[name] = data
+	}
+	for name, data := range c.ServerCert.certFiles {
+		files[name] = data
+	}
+	files[IPBlocklistFile] = c.Block.renderIPBlocklist()
+	return files, nil
+}
+
+// WriteTo renders Config and writes all files into BFE conf directory root
+func (c *Config) WriteTo(root string) error {
+	files, err := c.Render()
+	if err != nil {
+		return err
+	}
+	return WriteFiles(root, files)
+}
+
+// copyDir copies regular files and directories under src into dst, paths
+// in exclude are skipped
+func copyDir(src, dst string, exclude ...string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		for _, ex := range exclude {
+			if path == ex && info.IsDir() {
+				return filepath.SkipDir
+			}
+			if path == ex {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|ReadWriteByUser)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, data, info.Mode().Perm())
+	})
+}
+
+// fileMode returns permission of rendered file, htpasswd files holding
+// password hashes and pem files holding private keys are readable by the
+// owner only
+func fileMode(name string) os.FileMode {
+	if strings.HasPrefix(name, AuthBasicUserDir+"/") || strings.HasPrefix(name, ServerCertDir+"/") {
+		return 0600
+	}
+	return 0644
+}
+
+// WriteFiles writes rendered files into directory root
+func WriteFiles(root string, files map[string][]byte) error {
+	for name, data := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), ReadWriteByUser); err != nil {
+			return fmt.Errorf("create directory for %v error: %v", path, err)
+		}
+		// WriteFile keeps permission of an existing file
+		if err := ioutil.WriteFile(path, data, fileMode(name)); err != nil {
+			return fmt.Errorf("write %v error: %v", path, err)
+		}
+		if err := os.Chmod(path, fileMode(name)); err != nil {
+			return fmt.Errorf("chmod %v error: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	"github.com/baidu/ingress-bfe/internal/store"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// CheckIngress returns the problems sync would find in ingress. Ingresses in
+// store are translated together with ingress the same way as sync does, and
+// problems attributed to ingress are returned: invalid annotations, hosts,
+// paths and certificates, canaries without primary, backends which can not
+// be resolved, and paths owned by an older Ingress. Ingresses of other
+// classes are not checked.
+func (b *BfeController) CheckIngress(ing *networking.Ingress) error {
+	if !store.IsValid(ing) {
+		return nil
+	}
+
+	ing = ing.DeepCopy()
+	if ing.CreationTimestamp.IsZero() {
+		// a new ingress is the newest one
+		ing.CreationTimestamp = metav1.Now()
+	}
+	ingKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+
+	ingresses := b.store.ListIngresses(func(other *networking.Ingress) bool {
+		return !store.IsValid(other) || (other.Namespace == ing.Namespace && other.Name == ing.Name)
+	})
+	ingresses = append(ingresses, ing)
+	store.SortIngresses(ingresses)
+
+	recorder := &errorRecorder{ing: ing}
+	cfg := config.NewConfig()
+	t := b.translate(cfg, ingresses, recorder)
+
+	_, clusterErrs := b.addClusters(cfg, t.backends)
+	for name, err := range clusterErrs {
+		if containsString(cfg.Sources[name], ingKey) {
+			backend := t.backends[name]
+			recorder.Eventf(ing, apiv1.EventTypeWarning, "UNRESOLVED", "Error resolving backend %v:%v: %v",
+				backend.service, backend.port.String(), err)
+		}
+	}
+	for _, c := range t.owners.conflicts {
+		if c.Loser == ingKey {
+			recorder.Eventf(ing, apiv1.EventTypeWarning, "CONFLICT", "Ignoring %v: %v", c.subject(), c)
+		}
+	}
+
+	return utilerrors.NewAggregate(recorder.errs)
+}
+
+// errorRecorder is an EventRecorder collecting Warning Events of ing as
+// errors, other events are dropped
+type errorRecorder struct {
+	ing  *networking.Ingress
+	errs []error
+}
+
+func (r *errorRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if eventtype != apiv1.EventTypeWarning {
+		return
+	}
+	obj, ok := object.(metav1.Object)
+	if !ok || obj.GetNamespace() != r.ing.Namespace || obj.GetName() != r.ing.Name {
+		return
+	}
+	r.errs = append(r.errs, fmt.Errorf("%s", message))
+}
+
+func (r *errorRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func (r *errorRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Eventf(object, eventtype, reason, messageFmt, args...)
+}
@@ -0,0 +1,308 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/baidu/ingress-bfe/internal/admission"
+	"github.com/baidu/ingress-bfe/internal/annotations"
+	"github.com/baidu/ingress-bfe/internal/store"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func newTestIngress(name, host, path string, created time.Time, anns map[string]string) *networking.Ingress {
+	return &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			Annotations:       anns,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{{
+				Host: host,
+				IngressRuleValue: networking.IngressRuleValue{
+					HTTP: &networking.HTTPIngressRuleValue{
+						Paths: []networking.HTTPIngressPath{{
+							Path: path,
+							Backend: networking.IngressBackend{
+								ServiceName: "svc",
+								ServicePort: intstr.FromInt(80),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
+
+// withService sets backend service of the path of ingress
+func withService(ing *networking.Ingress, service string) *networking.Ingress {
+	ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName = service
+	return ing
+}
+
+// withBackend sets spec.backend of ingress
+func withBackend(ing *networking.Ingress, service string) *networking.Ingress {
+	ing.Spec.Backend = &networking.IngressBackend{
+		ServiceName: service,
+		ServicePort: intstr.FromInt(80),
+	}
+	return ing
+}
+
+// fakeStore is a Store of given ingresses, every Service of ingresses has
+// port 80 with a ready endpoint
+type fakeStore struct {
+	ingresses []*networking.Ingress
+	services  map[string]bool
+	certs     map[string]*store.SSLCert
+}
+
+func (s *fakeStore) GetSecret(key string) (*corev1.Secret, error) {
+	return nil, fmt.Errorf("secret %v not found", key)
+}
+
+func (s *fakeStore) GetService(key string) (*corev1.Service, error) {
+	if !s.services[key] {
+		return nil, fmt.Errorf("service %v not found", key)
+	}
+	return &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(8080)}},
+		},
+	}, nil
+}
+
+func (s *fakeStore) GetServiceEndpoints(key string) (*corev1.Endpoints, error) {
+	return &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+			Ports:     []corev1.EndpointPort{{Port: 8080, Protocol: corev1.ProtocolTCP}},
+		}},
+	}, nil
+}
+
+func (s *fakeStore) ListIngresses(filter store.IngressFilterFunc) []*networking.Ingress {
+	var ingresses []*networking.Ingress
+	for _, ing := range s.ingresses {
+		if filter == nil || !filter(ing) {
+			ingresses = append(ingresses, ing)
+		}
+	}
+	store.SortIngresses(ingresses)
+	return ingresses
+}
+
+func (s *fakeStore) Run(stopCh chan struct{}) {}
+
+func (s *fakeStore) GetLocalSSLCert(key string) (*store.SSLCert, error) {
+	cert, ok := s.certs[key]
+	if !ok {
+		return nil, fmt.Errorf("certificate %v not found", key)
+	}
+	return cert, nil
+}
+
+func (s *fakeStore) ListLocalSSLCerts() []*store.SSLCert {
+	var certs []*store.SSLCert
+	for _, cert := range s.certs {
+		certs = append(certs, cert)
+	}
+	return certs
+}
+
+func (s *fakeStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
+	return nil, fmt.Errorf("configmap %v not found", key)
+}
+
+// newCheckController returns a controller whose store contains ingresses
+// and Services default/svc and default/canary
+func newCheckController(ingresses ...*networking.Ingress) *BfeController {
+	return &BfeController{
+		store: &fakeStore{
+			ingresses: ingresses,
+			services:  map[string]bool{"default/svc": true, "default/canary": true},
+		},
+	}
+}
+
+func postReview(t *testing.T, handler http.Handler, apiVersion string, ing *networking.Ingress) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(ing)
+	if err != nil {
+		t.Fatalf("marshal Ingress error: %v", err)
+	}
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: apiVersion,
+			Kind:       "AdmissionReview",
+		},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid-1",
+			Resource:  metav1.GroupVersionResource{Group: "networking.k8s.io", Version: "v1beta1", Resource: "ingresses"},
+			Namespace: ing.Namespace,
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatalf("marshal AdmissionReview error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	resp := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(rec.Body.Bytes(), resp); err != nil {
+		t.Fatalf("unmarshal response error: %v", err)
+	}
+	if resp.APIVersion != apiVersion {
+		t.Errorf("apiVersion of response is %v, want %v", resp.APIVersion, apiVersion)
+	}
+	if resp.Response == nil || resp.Response.UID != "uid-1" {
+		t.Fatalf("response of uid-1 expected, got %+v", resp.Response)
+	}
+	return resp.Response
+}
+
+func TestCheckIngressReview(t *testing.T) {
+	now := time.Now()
+	owner := newTestIngress("owner", "foo.com", "/api", now.Add(-time.Hour), nil)
+	fallback := withBackend(newTestIngress("fallback", "foo.com", "/fallback", now.Add(-time.Hour), nil), "svc")
+	handler := &admission.Handler{Checker: newCheckController(owner, fallback)}
+
+	tests := []struct {
+		name    string
+		ing     *networking.Ingress
+		allowed bool
+		// msg is part of the message of a denied request
+		msg string
+	}{
+		{
+			name:    "valid",
+			ing:     newTestIngress("new", "foo.com", "/web", time.Time{}, nil),
+			allowed: true,
+		},
+		{
+			name: "bad annotation",
+			ing: newTestIngress("new", "foo.com", "/web", time.Time{}, map[string]string{
+				annotations.GetAnnotationWithPrefix(annotations.RouterHeaderKey): "no-colon",
+			}),
+			msg: annotations.GetAnnotationWithPrefix(annotations.RouterHeaderKey),
+		},
+		{
+			name: "invalid host",
+			ing:  newTestIngress("new", "foo.*.com", "/web", time.Time{}, nil),
+			msg:  "host foo.*.com",
+		},
+		{
+			name: "conflicting path",
+			ing:  newTestIngress("new", "foo.com", "/api", time.Time{}, nil),
+			msg:  "served by Ingress default/owner",
+		},
+		{
+			name:    "same path of another host",
+			ing:     newTestIngress("new", "bar.com", "/api", time.Time{}, nil),
+			allowed: true,
+		},
+		{
+			name: "same path with router predicate",
+			ing: newTestIngress("new", "foo.com", "/api", time.Time{}, map[string]string{
+				annotations.GetAnnotationWithPrefix(annotations.RouterHeaderKey): "X-Env: staging",
+			}),
+			allowed: true,
+		},
+		{
+			name:    "update of owner",
+			ing:     newTestIngress("owner", "foo.com", "/api", now.Add(-time.Hour), nil),
+			allowed: true,
+		},
+		{
+			name:    "older than owner",
+			ing:     newTestIngress("older", "foo.com", "/api", now.Add(-2*time.Hour), nil),
+			allowed: true,
+		},
+		{
+			name: "conflicting spec.backend",
+			ing:  withBackend(newTestIngress("new", "foo.com", "/web", time.Time{}, nil), "canary"),
+			msg:  "spec.backend of Ingress default/fallback",
+		},
+		{
+			name:    "spec.backend of another host",
+			ing:     withBackend(newTestIngress("new", "bar.com", "/web", time.Time{}, nil), "canary"),
+			allowed: true,
+		},
+		{
+			name: "unknown service",
+			ing:  withService(newTestIngress("new", "foo.com", "/web", time.Time{}, nil), "missing"),
+			msg:  "service default/missing not found",
+		},
+		{
+			name: "missing tls secret",
+			ing: func() *networking.Ingress {
+				ing := newTestIngress("new", "foo.com", "/web", time.Time{}, nil)
+				ing.Spec.TLS = []networking.IngressTLS{{Hosts: []string{"foo.com"}, SecretName: "tls"}}
+				return ing
+			}(),
+			msg: "certificate default/tls not found",
+		},
+		{
+			name: "canary without primary",
+			ing: withService(newTestIngress("canary", "foo.com", "/web", time.Time{}, map[string]string{
+				annotations.GetAnnotationWithPrefix(annotations.CanaryKey): "true",
+			}), "canary"),
+			msg: "no Ingress serves the path",
+		},
+		{
+			name: "canary of owned path",
+			ing: withService(newTestIngress("canary", "foo.com", "/api", time.Time{}, map[string]string{
+				annotations.GetAnnotationWithPrefix(annotations.CanaryKey): "true",
+			}), "canary"),
+			allowed: true,
+		},
+		{
+			name: "canary with redirect",
+			ing: withService(newTestIngress("canary", "foo.com", "/api", time.Time{}, map[string]string{
+				annotations.GetAnnotationWithPrefix(annotations.CanaryKey):            "true",
+				annotations.GetAnnotationWithPrefix(annotations.PermanentRedirectKey): "https://bar.com",
+			}), "canary"),
+			msg: "Ignoring redirect annotations of canary Ingress",
+		},
+	}
+
+	for _, test := range tests {
+		for _, apiVersion := range []string{"admission.k8s.io/v1", "admission.k8s.io/v1beta1"} {
+			resp := postReview(t, handler, apiVersion, test.ing)
+			if resp.Allowed != test.allowed {
+				t.Errorf("%v (%v): allowed is %v, want %v, result %+v", test.name, apiVersion, resp.Allowed, test.allowed, resp.Result)
+				continue
+			}
+			if test.allowed {
+				continue
+			}
+			if resp.Result == nil || resp.Result.Code != http.StatusUnprocessableEntity {
+				t.Errorf("%v (%v): code 422 expected, result %+v", test.name, apiVersion, resp.Result)
+				continue
+			}
+			if !strings.Contains(resp.Result.Message, test.msg) {
+				t.Errorf("%v (%v): message %q does not contain %q", test.name, apiVersion, resp.Result.Message, test.msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+const (
+	// authSecretKey is the key of Secret holding users in htpasswd format
+	authSecretKey = "auth"
+)
+
+// addAuthBasic adds mod_auth_basic rule of route matching the requests
+// routed by route. If users can not be read from the Secret, an empty user
+// file is used so that requests are rejected rather than let through.
+func (b *BfeController) addAuthBasic(cfg *config.Config, route ingressRoute, recorder record.EventRecorder) {
+	auth := route.anns.Auth
+	if auth.Secret == "" {
+		return
+	}
+
+	users, err := b.getAuthUsers(auth.Secret)
+	if err != nil {
+		klog.Warningf("Error reading users of Ingress %v/%v: %v", route.ing.Namespace, route.ing.Name, err)
+		recorder.Eventf(route.ing, apiv1.EventTypeWarning, "INVALID", "Error reading users of basic authentication: %v", err)
+	}
+
+	cond := route.moduleCond
+	if route.anns.CORS.Enabled {
+		// preflight requests carry no credentials, they are answered by mod_cors
+		cond = config.CondAnd(cond, config.CondNot(config.CondCORSPreflight))
+	}
+	cfg.AuthBasic.AddRule(route.product, config.AuthBasicRule{
+		Cond:     cond,
+		UserFile: cfg.AuthBasic.AddUserFile(auth.Secret, users),
+		Realm:    auth.Realm,
+	})
+}
+
+// getAuthUsers returns htpasswd content of Secret namespace/name
+func (b *BfeController) getAuthUsers(key string) ([]byte, error) {
+	secret, err := b.store.GetSecret(key)
+	if err != nil {
+		return []byte{}, fmt.Errorf("get Secret %v error: %v", key, err)
+	}
+	users, ok := secret.Data[authSecretKey]
+	if !ok {
+		return []byte{}, fmt.Errorf("key %q missing from Secret %v", authSecretKey, key)
+	}
+	return users, nil
+}
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/baidu/ingress-bfe/internal/annotations"
+	"github.com/baidu/ingress-bfe/internal/config"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// canaryRoute is a path of a canary Ingress
+type canaryRoute struct {
+	ingressRoute
+	canary annotations.Canary
+}
+
+// canarySplit is a cluster splitting traffic of a primary cluster to a
+// canary cluster by weight
+type canarySplit struct {
+	name    string
+	primary string
+	canary  string
+	weight  int
+}
+
+// canaryClusterName returns name of the cluster splitting primary and canary
+// cluster, "_" is not allowed in kubernetes names so it never collides with
+// clusters of services
+func canaryClusterName(primary, canary string) string {
+	return fmt.Sprintf("%s_canary_%s", primary, canary)
+}
+
+// canaryFeatures are the annotation features used by a canary Ingress,
+// requests routed to canary are processed by modules of the primary route
+var canaryFeatures = map[string]bool{
+	"canary":           true,
+	"router":           true,
+	"router.condition": true,
+}
+
+// checkCanaryAnnotations records a Warning Event if canary ingress sets
+// annotations of modules, e.g. rewrite or redirect. They are ignored, since
+// rules of the primary route apply to its canary routes.
+func checkCanaryAnnotations(ing *networking.Ingress, recorder record.EventRecorder) {
+	var ignored []string
+	for _, name := range annotations.Features(ing) {
+		if !canaryFeatures[name] {
+			ignored = append(ignored, name)
+		}
+	}
+	if len(ignored) == 0 {
+		return
+	}
+	klog.Warningf("Ignoring %v annotations of canary Ingress %v/%v", strings.Join(ignored, ","), ing.Namespace, ing.Name)
+	recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Ignoring %v annotations of canary Ingress, annotations of the primary Ingress apply", strings.Join(ignored, ","))
+}
+
+// addCanaryRoutes attaches canary paths to routes of the same host, path,
+// path type and router predicates. Requests with canary header or cookie are routed to canary
+// cluster by routes matched before the primary route, weighted canary
+// replaces cluster of the primary route by a split cluster. The oldest
+// canary of a path wins.
+func (b *BfeController) addCanaryRoutes(cfg *config.Config, routes []ingressRoute, canaries []canaryRoute, recorder record.EventRecorder) ([]ingressRoute, []canarySplit) {
+	var splits []canarySplit
+	hasCanary := make(map[int]bool)
+
+	for _, c := range canaries {
+		ingKey := fmt.Sprintf("%v/%v", c.ing.Namespace, c.ing.Name)
+
+		idx := -1
+		for i, route := range routes {
+			// cond of route covers path, path type and router predicates
+			if !route.canary && route.product == c.product && route.cond == c.cond {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			klog.Warningf("Ignoring canary path %v of Ingress %v: no Ingress serves the path", c.path, ingKey)
+			recorder.Eventf(c.ing, apiv1.EventTypeWarning, "INVALID", "Ignoring canary path %v: no Ingress serves the path", c.path)
+			continue
+		}
+		if routes[idx].cluster == c.cluster {
+			klog.Warningf("Ignoring canary path %v of Ingress %v: canary backend is the same as the primary backend", c.path, ingKey)
+			recorder.Eventf(c.ing, apiv1.EventTypeWarning, "INVALID", "Ignoring canary path %v: canary backend is the same as the primary backend", c.path)
+			continue
+		}
+		if hasCanary[idx] {
+			klog.Warningf("Ignoring canary path %v of Ingress %v: path has canary already", c.path, ingKey)
+			recorder.Eventf(c.ing, apiv1.EventTypeWarning, "CONFLICT", "Ignoring canary path %v: path has canary already", c.path)
+			continue
+		}
+		hasCanary[idx] = true
+		primary := routes[idx]
+
+		if c.canary.Header != "" {
+			route := primary
+			route.cond = config.CondAnd(primary.cond, config.CondHeaderValueIn(c.canary.Header, c.canary.HeaderValue))
+			route.predicates = primary.predicates + 1
+			route.cluster = c.cluster
+			route.canary = true
+			routes = append(routes, route)
+		}
+		if c.canary.Cookie != "" {
+			route := primary
+			route.cond = config.CondAnd(primary.cond, config.CondCookieValueIn(c.canary.Cookie, annotations.CanaryAlways))
+			route.predicates = primary.predicates + 1
+			route.cluster = c.cluster
+			route.canary = true
+			routes = append(routes, route)
+		}
+
+		if c.canary.Weight > 0 {
+			split := canarySplit{
+				name:    canaryClusterName(primary.cluster, c.cluster),
+				primary: primary.cluster,
+				canary:  c.cluster,
+				weight:  c.canary.Weight,
+			}
+			cfg.AddSource(split.name, ingKey)
+			routes[idx].cluster = split.name
+			splits = append(splits, split)
+		}
+	}
+
+	return routes, splits
+}
+
+// addCanarySplits creates split clusters with backends of primary and
+// canary clusters. Canary without backends gets no traffic.
+func (b *BfeController) addCanarySplits(cfg *config.Config, splits []canarySplit, endpoints map[string][]config.Backend) {
+	for _, split := range splits {
+		weight := split.weight
+		if len(endpoints[split.canary]) == 0 {
+			klog.Warningf("Canary cluster %v has no backends, traffic is kept on %v", split.canary, split.primary)
+			weight = 0
+		}
+
+		subClusters := map[string][]config.Backend{
+			split.primary: endpoints[split.primary],
+			split.canary:  endpoints[split.canary],
+		}
+		weights := map[string]int{
+			split.primary: 100 - weight,
+			split.canary:  weight,
+		}
+		cfg.AddWeightedCluster(split.name, subClusters, weights)
+	}
+}
@@ -0,0 +1,214 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+)
+
+const (
+	// defBackendWeight is the weight of each endpoint in sub cluster
+	defBackendWeight = 1
+)
+
+// serviceBackend is a service port referenced by ingress backend
+type serviceBackend struct {
+	namespace string
+	service   string
+	port      intstr.IntOrString
+}
+
+// addClusters creates a BFE cluster for each referenced service port, and
+// returns backends of each cluster. Errors of ports which can not be
+// resolved are returned by cluster, such clusters have no backends.
+func (b *BfeController) addClusters(cfg *config.Config, backends map[string]serviceBackend) (map[string][]config.Backend, map[string]error) {
+	clusters := make(map[string][]config.Backend)
+	errs := make(map[string]error)
+
+	for name, backend := range backends {
+		endpoints, err := b.getEndpoints(backend)
+		if err != nil {
+			errs[name] = err
+		}
+		cfg.AddCluster(name, endpoints)
+		clusters[name] = endpoints
+	}
+	return clusters, errs
+}
+
+// recordUnresolved records a Warning Event on ingresses referencing a port
+// which can not be resolved. Every Endpoints change triggers a sync, so a
+// port is only reported when it becomes unresolved or its error changes,
+// not on every sync.
+func (b *BfeController) recordUnresolved(cfg *config.Config, backends map[string]serviceBackend, errs map[string]error, ingresses []*networking.Ingress) {
+	unresolved := make(map[string]string, len(errs))
+	for name, err := range errs {
+		unresolved[name] = err.Error()
+		if b.unresolved[name] == err.Error() {
+			continue
+		}
+
+		backend := backends[name]
+		klog.Warningf("Error resolving backend of cluster %v: %v", name, err)
+		for _, ing := range ingresses {
+			ingKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+			if containsString(cfg.Sources[name], ingKey) {
+				b.recorder.Eventf(ing, corev1.EventTypeWarning, "UNRESOLVED", "Error resolving backend %v:%v: %v",
+					backend.service, backend.port.String(), err)
+			}
+		}
+	}
+	b.unresolved = unresolved
+}
+
+// retainExternalNames keeps cached addresses of ExternalName services
+// referenced by backends only
+func (b *BfeController) retainExternalNames(backends map[string]serviceBackend) {
+	hosts := make(map[string]bool)
+	for _, backend := range backends {
+		svc, err := b.store.GetService(fmt.Sprintf("%v/%v", backend.namespace, backend.service))
+		if err == nil && svc.Spec.Type == corev1.ServiceTypeExternalName {
+			hosts[svc.Spec.ExternalName] = true
+		}
+	}
+	b.externalNames.Retain(hosts)
+}
+
+// getEndpoints returns ready endpoints of service port. Port of endpoints
+// is resolved per subset, since a named targetPort may be a different
+// number in each pod. An error is returned if service or port is not
+// found, or the port is not found in endpoints.
+func (b *BfeController) getEndpoints(backend serviceBackend) ([]config.Backend, error) {
+	key := fmt.Sprintf("%v/%v", backend.namespace, backend.service)
+
+	svc, err := b.store.GetService(key)
+	if err != nil {
+		return nil, fmt.Errorf("get Service %v error: %v", key, err)
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeExternalName {
+		return b.getExternalNameEndpoints(svc, backend)
+	}
+
+	svcPort := findServicePort(svc, backend.port)
+	if svcPort == nil {
+		return nil, fmt.Errorf("Service %v does not have port %v", key, backend.port.String())
+	}
+
+	eps, err := b.store.GetServiceEndpoints(key)
+	if err != nil {
+		return nil, fmt.Errorf("get Endpoints %v error: %v", key, err)
+	}
+
+	var backends []config.Backend
+	var unresolved int
+	for _, subset := range eps.Subsets {
+		port, ok := resolveEndpointPort(svc, svcPort, subset)
+		if !ok {
+			unresolved++
+			continue
+		}
+		// NotReadyAddresses are excluded
+		for _, addr := range subset.Addresses {
+			backends = append(backends, config.Backend{
+				Name:   endpointName(addr),
+				Addr:   addr.IP,
+				Port:   int(port),
+				Weight: defBackendWeight,
+			})
+		}
+	}
+
+	if unresolved > 0 && len(backends) == 0 {
+		return nil, fmt.Errorf("port %v of Service %v not found in Endpoints", backend.port.String(), key)
+	}
+	return backends, nil
+}
+
+// getExternalNameEndpoints returns resolved addresses of ExternalName
+// service. ExternalName service may have no ports, the port number of
+// backend is used if the service does not define it. No endpoint is
+// returned until the name is resolved in background, which triggers a
+// new sync.
+func (b *BfeController) getExternalNameEndpoints(svc *corev1.Service, backend serviceBackend) ([]config.Backend, error) {
+	key := fmt.Sprintf("%v/%v", backend.namespace, backend.service)
+
+	var port int32
+	if svcPort := findServicePort(svc, backend.port); svcPort != nil {
+		port = svcPort.Port
+	} else if backend.port.Type == intstr.Int {
+		port = backend.port.IntVal
+	} else {
+		return nil, fmt.Errorf("Service %v does not have port %v", key, backend.port.String())
+	}
+
+	addrs, err := b.externalNames.Lookup(svc.Spec.ExternalName)
+	if err != nil {
+		return nil, fmt.Errorf("resolve ExternalName %v of Service %v error: %v", svc.Spec.ExternalName, key, err)
+	}
+
+	backends := make([]config.Backend, 0, len(addrs))
+	for _, addr := range addrs {
+		backends = append(backends, config.Backend{
+			Name:   svc.Spec.ExternalName,
+			Addr:   addr,
+			Port:   int(port),
+			Weight: defBackendWeight,
+		})
+	}
+	return backends, nil
+}
+
+// resolveEndpointPort returns port of endpoints in subset serving service
+// port. Endpoints controller names endpoint ports after service ports, a
+// port of endpoints managed by user is matched by targetPort number, or is
+// used if it is the only port of a single port service.
+func resolveEndpointPort(svc *corev1.Service, svcPort *corev1.ServicePort, subset corev1.EndpointSubset) (int32, bool) {
+	for _, epPort := range subset.Ports {
+		if epPort.Protocol != corev1.ProtocolTCP {
+			continue
+		}
+		if epPort.Name == svcPort.Name {
+			return epPort.Port, true
+		}
+	}
+
+	if svcPort.TargetPort.Type == intstr.Int && svcPort.TargetPort.IntVal != 0 {
+		for _, epPort := range subset.Ports {
+			if epPort.Protocol == corev1.ProtocolTCP && epPort.Port == svcPort.TargetPort.IntVal {
+				return epPort.Port, true
+			}
+		}
+	}
+
+	if len(svc.Spec.Ports) == 1 && len(subset.Ports) == 1 && subset.Ports[0].Protocol == corev1.ProtocolTCP {
+		return subset.Ports[0].Port, true
+	}
+	return 0, false
+}
+
+// findServicePort returns service port matching port number or name
+func findServicePort(svc *corev1.Service, port intstr.IntOrString) *corev1.ServicePort {
+	for i := range svc.Spec.Ports {
+		p := &svc.Spec.Ports[i]
+		if port.Type == intstr.Int && p.Port == port.IntVal {
+			return p
+		}
+		if port.Type == intstr.String && p.Name == port.StrVal {
+			return p
+		}
+	}
+	return nil
+}
+
+// endpointName returns pod name of endpoint, or ip if endpoint is not a pod
+func endpointName(addr corev1.EndpointAddress) string {
+	if addr.TargetRef != nil && addr.TargetRef.Name != "" {
+		return addr.TargetRef.Name
+	}
+	return addr.IP
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRecordUnresolved(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	b := &BfeController{recorder: recorder}
+
+	ing := newTestIngress("foo", "foo.com", "/", time.Now(), nil)
+	ingresses := []*networking.Ingress{ing}
+	cfg := config.NewConfig()
+	cfg.AddSource("default_svc_80", "default/foo")
+	backends := map[string]serviceBackend{
+		"default_svc_80": {namespace: "default", service: "svc", port: intstr.FromInt(80)},
+	}
+
+	notFound := map[string]error{"default_svc_80": errors.New("service not found")}
+	noPort := map[string]error{"default_svc_80": errors.New("port not found")}
+	syncs := []struct {
+		errs       map[string]error
+		wantEvents int
+	}{
+		{errs: notFound, wantEvents: 1},
+		// unchanged errors are not reported again
+		{errs: notFound, wantEvents: 0},
+		{errs: noPort, wantEvents: 1},
+		{errs: nil, wantEvents: 0},
+		// unresolved again after it was resolved
+		{errs: noPort, wantEvents: 1},
+	}
+
+	for i, sync := range syncs {
+		b.recordUnresolved(cfg, backends, sync.errs, ingresses)
+		if got := len(recorder.Events); got != sync.wantEvents {
+			t.Errorf("sync %d: %d events recorded, want %d", i, got, sync.wantEvents)
+		}
+		for len(recorder.Events) > 0 {
+			<-recorder.Events
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/baidu/ingress-bfe/internal/annotations"
+	"k8s.io/klog"
+)
+
+// keys of the controller ConfigMap
+const (
+	// globalDenylistKey is the comma separated CIDRs blocked for all hosts
+	globalDenylistKey = "global-denylist"
+	// useForwardedClientIPKey makes ip access control match the real
+	// client address derived by BFE from trusted proxies, rather than the
+	// peer address of connections
+	useForwardedClientIPKey = "use-forwarded-client-ip"
+)
+
+// globalConfig is the global settings of the controller ConfigMap
+type globalConfig struct {
+	denylist             []*net.IPNet
+	useForwardedClientIP bool
+}
+
+// getGlobalConfig returns settings of the controller ConfigMap, invalid
+// settings are ignored
+func (b *BfeController) getGlobalConfig() globalConfig {
+	var global globalConfig
+	if b.config.ConfigMap == "" {
+		return global
+	}
+
+	cm, err := b.store.GetConfigMap(b.config.ConfigMap)
+	if err != nil {
+		klog.Warningf("Error getting ConfigMap %v: %v", b.config.ConfigMap, err)
+		return global
+	}
+
+	if val, ok := cm.Data[globalDenylistKey]; ok {
+		nets, err := annotations.ParseCIDRs(val)
+		if err != nil {
+			klog.Warningf("Ignoring %v of ConfigMap %v: %v", globalDenylistKey, b.config.ConfigMap, err)
+		} else {
+			global.denylist = nets
+		}
+	}
+	if val, ok := cm.Data[useForwardedClientIPKey]; ok {
+		use, err := strconv.ParseBool(val)
+		if err != nil {
+			klog.Warningf("Ignoring %v of ConfigMap %v: %v", useForwardedClientIPKey, b.config.ConfigMap, err)
+		} else {
+			global.useForwardedClientIP = use
+		}
+	}
+
+	return global
+}
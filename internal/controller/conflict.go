@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/klog"
+)
+
+// Conflict is a host and path, or the spec.backend fallback of a host,
+// claimed by more than one Ingress
+type Conflict struct {
+	Host string
+	// Path is empty for a conflict of spec.backend
+	Path     string
+	PathType networking.PathType
+	// Winner is namespace/name of the oldest Ingress, which serves the path
+	Winner string
+	// Loser is namespace/name of the Ingress whose path is ignored
+	Loser string
+}
+
+func (c Conflict) String() string {
+	host := c.Host
+	if host == "" {
+		host = "*"
+	}
+	if c.Path == "" {
+		return fmt.Sprintf("default backend of host %v is spec.backend of Ingress %v", host, c.Winner)
+	}
+	return fmt.Sprintf("path %v (%v) of host %v is served by Ingress %v", c.Path, c.PathType, host, c.Winner)
+}
+
+// subject returns what the losing Ingress loses
+func (c Conflict) subject() string {
+	if c.Path == "" {
+		return "spec.backend"
+	}
+	return "path " + c.Path
+}
+
+// routeOwners finds conflicting paths. The first Ingress adding a path of a
+// host owns it, ingresses are sorted so the oldest one wins.
+type routeOwners struct {
+	// owners maps host and route condition to the owner Ingress
+	owners    map[string]*networking.Ingress
+	conflicts []Conflict
+}
+
+func newRouteOwners() *routeOwners {
+	return &routeOwners{
+		owners: make(map[string]*networking.Ingress),
+	}
+}
+
+// ownerKey returns the key of host and route condition in routeOwners
+func ownerKey(host, cond string) string {
+	return fmt.Sprintf("%s %s", host, cond)
+}
+
+// claim returns true if path of host is claimed by ing for the first time
+// or is already owned by it, otherwise a conflict is recorded. Paths of
+// different route conditions, e.g. by router annotations, never conflict.
+func (r *routeOwners) claim(ing *networking.Ingress, host string, path networking.HTTPIngressPath, cond string) bool {
+	return r.claimKey(ing, ownerKey(host, cond), Conflict{
+		Host:     host,
+		Path:     path.Path,
+		PathType: pathType(path),
+	})
+}
+
+// claimBackend returns true if spec.backend of ing becomes or already is
+// the fallback of host, otherwise a conflict is recorded
+func (r *routeOwners) claimBackend(ing *networking.Ingress, host string) bool {
+	return r.claimKey(ing, ownerKey(host, "spec.backend"), Conflict{Host: host})
+}
+
+func (r *routeOwners) claimKey(ing *networking.Ingress, key string, conflict Conflict) bool {
+	owner, ok := r.owners[key]
+	if !ok {
+		r.owners[key] = ing
+		return true
+	}
+	if owner.Namespace == ing.Namespace && owner.Name == ing.Name {
+		return true
+	}
+
+	conflict.Winner = fmt.Sprintf("%v/%v", owner.Namespace, owner.Name)
+	conflict.Loser = fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+	r.conflicts = append(r.conflicts, conflict)
+	return false
+}
+
+// setConflicts replaces active conflicts. A conflict is logged and recorded
+// as a Warning Event of the losing Ingress only when it first appears, not
+// on every sync.
+func (b *BfeController) setConflicts(conflicts []Conflict, ingresses []*networking.Ingress) {
+	b.conflictsLock.Lock()
+	defer b.conflictsLock.Unlock()
+
+	active := make(map[Conflict]bool, len(b.conflicts))
+	for _, c := range b.conflicts {
+		active[c] = true
+	}
+	ingMap := make(map[string]*networking.Ingress, len(ingresses))
+	for _, ing := range ingresses {
+		ingMap[fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)] = ing
+	}
+	for _, c := range conflicts {
+		if active[c] {
+			continue
+		}
+		klog.Warningf("Ignoring %v of Ingress %v: %v", c.subject(), c.Loser, c)
+		if ing, ok := ingMap[c.Loser]; ok {
+			b.recorder.Eventf(ing, apiv1.EventTypeWarning, "CONFLICT", "Ignoring %v: %v", c.subject(), c)
+		}
+	}
+
+	b.conflicts = conflicts
+}
+
+// Conflicts returns conflicting paths found by the last sync, they are
+// served on /debug/conflicts of the metrics server
+func (b *BfeController) Conflicts() []Conflict {
+	b.conflictsLock.RLock()
+	defer b.conflictsLock.RUnlock()
+	conflicts := make([]Conflict, len(b.conflicts))
+	copy(conflicts, b.conflicts)
+	return conflicts
+}
@@ -2,17 +2,22 @@ package controller
 
 import (
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"os/exec"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/baidu/ingress-bfe/internal/admission"
 	"github.com/baidu/ingress-bfe/internal/bfe"
 	"github.com/baidu/ingress-bfe/internal/config"
 	"github.com/baidu/ingress-bfe/internal/queue"
 	"github.com/baidu/ingress-bfe/internal/store"
 	"github.com/eapache/channels"
 	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -34,15 +39,42 @@ type BfeController struct {
 	store           store.Store
 	isShuttiingDown bool
 	command         *bfe.Command
+	reloader        *bfe.Reloader
+	versions        *config.VersionStore
 	bfeErrCh        chan error
+
+	// sectionHashes are hashes of config sections BFE has loaded
+	sectionHashes map[string]string
+	// renderedFiles are files of the previous render in dry run mode
+	renderedFiles map[string][]byte
+
+	// webhook is the validating admission webhook server, nil if disabled
+	webhook *http.Server
+	// metrics is the server of metrics and debug endpoints, nil if disabled
+	metrics *http.Server
+
+	// externalNames resolves hosts of ExternalName services
+	externalNames *externalNameResolver
+
+	// conflicts are paths claimed by more than one Ingress
+	conflicts     []Conflict
+	conflictsLock sync.RWMutex
+
+	// unresolved maps clusters whose backends can not be resolved to the
+	// error reported by the last sync, it is used by sync only
+	unresolved map[string]string
 }
 
 func NewBfeController(kubeClient kubernetes.Interface, cfg config.Configuration) (controller *BfeController) {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
-	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
-		Interface: kubeClient.CoreV1().Events(cfg.Namespace),
-	})
+	// nothing is written to the API server in dry run mode, events are
+	// logged only
+	if !cfg.DryRun {
+		eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
+			Interface: kubeClient.CoreV1().Events(cfg.Namespace),
+		})
+	}
 	controller = &BfeController{
 		kubeClient: kubeClient,
 		config:     cfg,
@@ -52,11 +84,29 @@ func NewBfeController(kubeClient kubernetes.Interface, cfg config.Configuration)
 		stopCh:   make(chan struct{}),
 		updateCh: channels.NewRingChannel(1024),
 		command:  bfe.NewCommand(),
+		reloader: bfe.NewReloader(),
+		bfeErrCh: make(chan error),
 	}
-	controller.store = store.NewStore(kubeClient, cfg.Namespace, cfg.ResycPeriod, controller.updateCh)
+	controller.versions = config.NewVersionStore(controller.command.ConfPath, cfg.MaxConfigVersions)
+	// bfe runs with the current version
+	controller.command.ConfPath = controller.versions.Link
+	controller.externalNames = newExternalNameResolver(net.DefaultResolver, cfg.ExternalNameResolvePeriod, func() {
+		controller.updateCh.In() <- store.Event{
+			Type: store.UpdateEvent,
+			Obj:  queue.GetDummyObject("externalname-change"),
+		}
+	})
+	controller.store = store.NewStore(kubeClient, cfg.Namespace, cfg.ResycPeriod, controller.updateCh, controller.recorder)
 
 	controller.syncQueue = queue.NewTaskQueue(controller.syncIngress)
 
+	if cfg.ValidationWebhook != "" {
+		controller.webhook = admission.NewServer(cfg.ValidationWebhook, controller)
+	}
+	if cfg.MetricsAddr != "" {
+		controller.metrics = controller.newMetricsServer(cfg.MetricsAddr)
+	}
+
 	return controller
 }
 
@@ -66,14 +116,42 @@ func (b *BfeController) Run() {
 
 	b.store.Run(b.stopCh)
 
-	//start bfe process
-	cmd := b.command.ExecCommand()
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
+	if b.config.DryRun {
+		klog.Info("Running in dry run mode, bfe is not started")
+	} else {
+		if err := b.versions.Init(); err != nil {
+			klog.Fatalf("init bfe config versions error: %v", err)
+		}
+
+		//start bfe process
+		cmd := b.command.ExecCommand()
+		cmd.SysProcAttr = &syscall.SysProcAttr{
+			Setpgid: true,
+			Pgid:    0,
+		}
+		b.start(cmd)
+		b.startBuiltinBackend()
 	}
-	b.start(cmd)
 	go b.syncQueue.Run(time.Second, b.stopCh)
+	go b.externalNames.Run(b.stopCh)
+
+	if b.webhook != nil {
+		go func() {
+			klog.Infof("Starting validating admission webhook on %v", b.webhook.Addr)
+			err := b.webhook.ListenAndServeTLS(b.config.ValidationWebhookCertPath, b.config.ValidationWebhookKeyPath)
+			if err != nil && err != http.ErrServerClosed {
+				klog.Fatalf("validating admission webhook error: %v", err)
+			}
+		}()
+	}
+	if b.metrics != nil {
+		go func() {
+			klog.Infof("Starting metrics server on %v", b.metrics.Addr)
+			if err := b.metrics.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				klog.Errorf("metrics server error: %v", err)
+			}
+		}()
+	}
 
 	for {
 		select {
@@ -89,7 +167,7 @@ func (b *BfeController) Run() {
 				break
 			}
 			if evt, ok := event.(store.Event); ok {
-				klog.V(3).Info("Event %v received - object %v", evt.Type, evt.Obj)
+				klog.V(3).Infof("Event %v received - object %v", evt.Type, evt.Obj)
 				if evt.Type == store.ConfigurationEvent {
 					b.syncQueue.EnqueueTask(queue.GetDummyObject("configmap-change"))
 				}
@@ -109,7 +187,7 @@ func (b *BfeController) start(cmd *exec.Cmd) {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Start(); err != nil {
-		klog.Fatal("bfe start error:%v", err)
+		klog.Fatalf("bfe start error:%v", err)
 		b.bfeErrCh <- err
 		return
 	}
@@ -128,6 +206,17 @@ func (b *BfeController) Stop() error {
 	close(b.stopCh)
 	go b.syncQueue.Shutdown()
 
+	if b.webhook != nil {
+		b.webhook.Close()
+	}
+	if b.metrics != nil {
+		b.metrics.Close()
+	}
+
+	if b.config.DryRun {
+		return nil
+	}
+
 	//send stop signal to bfe
 	klog.Info("Stopping bfe process")
 	if err := b.command.Cmd.Process.Signal(syscall.SIGTERM); err != nil {
@@ -139,9 +228,120 @@ func (b *BfeController) Stop() error {
 	return nil
 }
 
-// syncIngress collects all the pieces required to assemble the NGINX
-// configuration file and passes the resulting data structures to the backend
-// (OnUpdate) when a reload is deemed necessary.
-func (n *BfeController) syncIngress(interface{}) error {
+// syncIngress collects all the pieces required to assemble the BFE
+// configuration files, renders them into a new config version, validates
+// it, switches the BFE conf directory to it and reloads the running BFE.
+// Only changed sections are reloaded, the previous version is restored if
+// reload fails.
+func (b *BfeController) syncIngress(interface{}) error {
+	ingresses := b.store.ListIngresses(func(ing *networking.Ingress) bool {
+		return !store.IsValid(ing)
+	})
+
+	cfg := b.getConfiguration(ingresses)
+	if b.config.DryRun {
+		// a fixed version keeps the diff of unchanged files empty
+		cfg.Version = dryRunVersion
+	}
+	hashes, err := cfg.Hash()
+	if err != nil {
+		return fmt.Errorf("hash bfe config error: %v", err)
+	}
+	changed := config.ChangedSections(b.sectionHashes, hashes)
+	for _, section := range config.Sections() {
+		if !containsString(changed, section) {
+			reloadSkipped.Add(section, 1)
+		}
+	}
+	if len(changed) == 0 {
+		klog.V(3).Infof("bfe config not changed, skip reload")
+		return nil
+	}
+
+	files, err := cfg.Render()
+	if err != nil {
+		return fmt.Errorf("render bfe config error: %v", err)
+	}
+
+	if b.config.DryRun {
+		b.sectionHashes = hashes
+		return b.dryRun(files)
+	}
+
+	dir, err := b.versions.Create(cfg.Version, files)
+	if err != nil {
+		return fmt.Errorf("create bfe config version %v error: %v", cfg.Version, err)
+	}
+
+	// invalid config is never switched to
+	if err := b.testConfiguration(cfg, dir, ingresses); err != nil {
+		if rerr := b.versions.Remove(cfg.Version); rerr != nil {
+			klog.Warningf("remove bfe config version %v error: %v", cfg.Version, rerr)
+		}
+		return err
+	}
+
+	if err := b.versions.Switch(cfg.Version); err != nil {
+		return fmt.Errorf("switch bfe config to version %v error: %v", cfg.Version, err)
+	}
+	klog.V(3).Infof("bfe config version %v applied, %d ingresses", cfg.Version, len(ingresses))
+
+	if err := b.reload(changed); err != nil {
+		b.rollback(changed)
+		return err
+	}
+	b.sectionHashes = hashes
+
+	if err := b.versions.Prune(); err != nil {
+		klog.Warningf("prune bfe config versions error: %v", err)
+	}
+
 	return nil
 }
+
+// reload reloads given config sections of the running BFE
+func (b *BfeController) reload(sections []string) error {
+	results, err := b.reloader.Reload(sections...)
+	for _, result := range results {
+		if result.Err != nil {
+			reloadFailed.Add(result.Target, 1)
+		} else {
+			reloadPerformed.Add(result.Target, 1)
+		}
+	}
+	return err
+}
+
+// rollback switches BFE conf directory to the previous version and reloads
+// given sections
+func (b *BfeController) rollback(sections []string) {
+	version, err := b.versions.Rollback()
+	if err != nil {
+		klog.Errorf("rollback bfe config error: %v", err)
+		return
+	}
+	klog.Warningf("rollback bfe config to version %v", version)
+
+	if err := b.reload(sections); err != nil {
+		klog.Errorf("reload bfe config version %v error: %v", version, err)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigVersions returns retained BFE config versions for debugging, they
+// are served on /debug/config-versions of the metrics server. No version is
+// retained in dry run mode.
+func (b *BfeController) ConfigVersions() ([]config.Version, error) {
+	if b.config.DryRun {
+		return []config.Version{}, nil
+	}
+	return b.versions.List()
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	"github.com/baidu/ingress-bfe/internal/config"
+)
+
+// addCORS adds mod_cors rule of route matching the requests routed by
+// route. Browsers send no custom headers or cookies in preflight requests,
+// so for route with predicates another rule answers preflight requests of
+// its path.
+func addCORS(cfg *config.Config, route ingressRoute) {
+	cors := route.anns.CORS
+	if !cors.Enabled {
+		return
+	}
+
+	rule := config.CORSRule{
+		Cond:                          route.moduleCond,
+		AccessControlAllowOrigins:     cors.AllowOrigins,
+		AccessControlAllowCredentials: cors.AllowCredentials,
+		AccessControlAllowMethods:     cors.AllowMethods,
+		AccessControlAllowHeaders:     cors.AllowHeaders,
+		AccessControlMaxAge:           cors.MaxAge,
+	}
+	cfg.CORS.AddRule(route.product, rule)
+
+	if route.predicates > 0 {
+		rule.Cond = config.CondAnd(config.CondCORSPreflight, route.pathCond)
+		cfg.CORS.AddRule(route.product, rule)
+	}
+}
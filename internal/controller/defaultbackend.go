@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog"
+)
+
+const (
+	// builtinBackendCluster is the cluster of the built-in default backend.
+	// Upper case letters are not allowed in kubernetes names, so it never
+	// collides with clusters of services.
+	builtinBackendCluster = "BUILTIN_DEFAULT_BACKEND"
+
+	// builtinBackendAddr is the address the built-in default backend
+	// listens on, BFE runs in the same network namespace as the controller
+	builtinBackendAddr = "127.0.0.1"
+	// DefBuiltinBackendPort is the default port of the built-in default backend
+	DefBuiltinBackendPort = 8181
+)
+
+// builtinBackendPort returns the port the built-in default backend listens on
+func (b *BfeController) builtinBackendPort() int {
+	if b.config.DefaultBackendPort > 0 {
+		return b.config.DefaultBackendPort
+	}
+	return DefBuiltinBackendPort
+}
+
+// startBuiltinBackend starts the built-in default backend answering every
+// request with 404
+func (b *BfeController) startBuiltinBackend() {
+	addr := fmt.Sprintf("%s:%d", builtinBackendAddr, b.builtinBackendPort())
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "default backend - 404", http.StatusNotFound)
+	})
+
+	go func() {
+		klog.Infof("Starting built-in default backend on %v", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			klog.Errorf("built-in default backend error: %v", err)
+		}
+	}()
+}
+
+// addDefaultBackend adds a fallback route to every product. Unmatched
+// requests of a product go to the backend of the oldest Ingress defining
+// spec.backend for its hosts, otherwise to the default backend service, or
+// the built-in default backend if no service is configured. Unknown hosts
+// are served by the default product.
+func (b *BfeController) addDefaultBackend(cfg *config.Config, fallbacks map[string]string, backends map[string]serviceBackend) {
+	defCluster := builtinBackendCluster
+	if backend, ok := b.defaultBackendService(); ok {
+		defCluster = clusterName(backend.namespace, backend.service, backend.port)
+		backends[defCluster] = backend
+	} else {
+		cfg.AddCluster(builtinBackendCluster, []config.Backend{{
+			Name:   builtinBackendCluster,
+			Addr:   builtinBackendAddr,
+			Port:   b.builtinBackendPort(),
+			Weight: defBackendWeight,
+		}})
+	}
+
+	cfg.HostRule.DefaultProduct = config.DefaultProduct
+	products := []string{config.DefaultProduct}
+	for product := range cfg.HostRule.HostTags {
+		products = append(products, product)
+	}
+	for _, product := range products {
+		cluster, ok := fallbacks[product]
+		if !ok {
+			cluster = defCluster
+		}
+		cfg.RouteRule.AddRule(product, config.RouteRule{
+			Cond:        config.CondDefault,
+			ClusterName: cluster,
+		})
+	}
+}
+
+// addIngressBackend records spec.backend of ingress as the fallback of
+// products of its hosts, or of the default product if it has no rules. The
+// oldest Ingress wins a product, others are recorded as conflicts.
+func (b *BfeController) addIngressBackend(cfg *config.Config, ing *networking.Ingress, owners *routeOwners, fallbacks map[string]string, backends map[string]serviceBackend) {
+	if ing.Spec.Backend == nil {
+		return
+	}
+	ingKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+	cluster := clusterName(ing.Namespace, ing.Spec.Backend.ServiceName, ing.Spec.Backend.ServicePort)
+
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		if validateHost(rule.Host) != nil {
+			continue
+		}
+		if rule.Host != "" {
+			cfg.HostRule.AddHost(productName(rule.Host), rule.Host)
+		}
+		hosts = append(hosts, rule.Host)
+	}
+	if len(ing.Spec.Rules) == 0 {
+		hosts = append(hosts, "")
+	}
+
+	for _, host := range hosts {
+		// ingresses are sorted, the oldest one wins
+		if !owners.claimBackend(ing, host) {
+			continue
+		}
+		product := productName(host)
+		fallbacks[product] = cluster
+		cfg.AddSource(product, ingKey)
+		cfg.AddSource(cluster, ingKey)
+		backends[cluster] = serviceBackend{
+			namespace: ing.Namespace,
+			service:   ing.Spec.Backend.ServiceName,
+			port:      ing.Spec.Backend.ServicePort,
+		}
+	}
+}
+
+// defaultBackendService returns the port of default backend service given
+// as namespace/name:port, port is a number or name. The first port of the
+// service is used if port is omitted.
+func (b *BfeController) defaultBackendService() (serviceBackend, bool) {
+	ref := b.config.DefaultBackendService
+	if ref == "" {
+		return serviceBackend{}, false
+	}
+
+	key, portRef := ref, ""
+	if i := strings.LastIndex(ref, ":"); i >= 0 {
+		key, portRef = ref[:i], ref[i+1:]
+	}
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 || (strings.Contains(ref, ":") && portRef == "") {
+		klog.Warningf("Invalid default backend service %v, namespace/name[:port] is expected", ref)
+		return serviceBackend{}, false
+	}
+	svc, err := b.store.GetService(key)
+	if err != nil {
+		klog.Warningf("Error getting default backend service %v: %v", key, err)
+		return serviceBackend{}, false
+	}
+	if len(svc.Spec.Ports) == 0 {
+		klog.Warningf("Default backend service %v has no port", key)
+		return serviceBackend{}, false
+	}
+
+	port := intstr.FromInt(int(svc.Spec.Ports[0].Port))
+	if portRef != "" {
+		port = intstr.Parse(portRef)
+		if findServicePort(svc, port) == nil {
+			klog.Warningf("Default backend service %v does not have port %v", key, portRef)
+			return serviceBackend{}, false
+		}
+	}
+
+	return serviceBackend{
+		namespace: parts[0],
+		service:   parts[1],
+		port:      port,
+	}, true
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	"k8s.io/klog"
+)
+
+const (
+	// dryRunVersion is the version of config rendered in dry run mode
+	dryRunVersion = "dry-run"
+)
+
+// dryRun writes rendered files into the dry run directory, or prints the
+// unified diff against the previous render to stdout. BFE is never touched.
+func (b *BfeController) dryRun(files map[string][]byte) error {
+	defer func() {
+		b.renderedFiles = files
+	}()
+
+	if b.config.DryRunDir != "" {
+		if err := config.WriteFiles(b.config.DryRunDir, files); err != nil {
+			return fmt.Errorf("write dry run config error: %v", err)
+		}
+		// files of the previous render not rendered any more, e.g.
+		// htpasswd files of removed Secrets, are deleted
+		for name := range b.renderedFiles {
+			if _, ok := files[name]; ok {
+				continue
+			}
+			if err := os.Remove(filepath.Join(b.config.DryRunDir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("remove dry run config error: %v", err)
+			}
+		}
+		klog.Infof("bfe config written to %v", b.config.DryRunDir)
+		return nil
+	}
+
+	fmt.Fprint(os.Stdout, config.Diff(b.renderedFiles, files))
+	return nil
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	// defResolvePeriod is the interval of re-resolving if none is configured
+	defResolvePeriod = 30 * time.Second
+	// defResolveTimeout is the timeout of resolving an ExternalName
+	defResolveTimeout = 5 * time.Second
+)
+
+// HostResolver resolves host names into addresses, net.Resolver implements
+// it. A fake resolver may be used in tests.
+type HostResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// externalNameResolver caches addresses of ExternalName services, and
+// resolves them in background so that sync is not blocked by DNS
+type externalNameResolver struct {
+	resolver HostResolver
+	period   time.Duration
+	// onChange is called when addresses of a host are resolved or changed
+	onChange func()
+
+	lock  sync.Mutex
+	addrs map[string][]string
+	// errs are errors of hosts never resolved successfully, they are
+	// retried every period
+	errs map[string]error
+	// pending are hosts being resolved for the first time
+	pending map[string]bool
+}
+
+func newExternalNameResolver(resolver HostResolver, period time.Duration, onChange func()) *externalNameResolver {
+	if period <= 0 {
+		period = defResolvePeriod
+	}
+	return &externalNameResolver{
+		resolver: resolver,
+		period:   period,
+		onChange: onChange,
+		addrs:    make(map[string][]string),
+		errs:     make(map[string]error),
+		pending:  make(map[string]bool),
+	}
+}
+
+// Lookup returns cached addresses of host. Host not in cache is resolved
+// in background, nil is returned until it is resolved and onChange is
+// called. The last error is returned if host was never resolved.
+func (r *externalNameResolver) Lookup(host string) ([]string, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if addrs, ok := r.addrs[host]; ok {
+		return addrs, nil
+	}
+	if err, ok := r.errs[host]; ok {
+		return nil, err
+	}
+	if !r.pending[host] {
+		r.pending[host] = true
+		go r.resolvePending(host)
+	}
+	return nil, nil
+}
+
+// resolvePending resolves host not in cache, onChange is called if it is
+// resolved
+func (r *externalNameResolver) resolvePending(host string) {
+	addrs, err := r.resolve(host)
+
+	r.lock.Lock()
+	retained := r.pending[host]
+	delete(r.pending, host)
+	if retained {
+		if err != nil {
+			klog.Warningf("Error resolving ExternalName %v: %v", host, err)
+			r.errs[host] = err
+		} else {
+			r.addrs[host] = addrs
+		}
+	}
+	r.lock.Unlock()
+
+	if retained && r.onChange != nil {
+		r.onChange()
+	}
+}
+
+// Retain removes cached hosts not in hosts
+func (r *externalNameResolver) Retain(hosts map[string]bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for host := range r.addrs {
+		if !hosts[host] {
+			delete(r.addrs, host)
+		}
+	}
+	for host := range r.errs {
+		if !hosts[host] {
+			delete(r.errs, host)
+		}
+	}
+	for host := range r.pending {
+		if !hosts[host] {
+			delete(r.pending, host)
+		}
+	}
+}
+
+// Run re-resolves cached hosts every period until stopCh is closed
+func (r *externalNameResolver) Run(stopCh chan struct{}) {
+	wait.Until(r.refresh, r.period, stopCh)
+}
+
+// refresh re-resolves cached and failed hosts, onChange is called if any
+// of them changes. Addresses are kept if resolving fails.
+func (r *externalNameResolver) refresh() {
+	r.lock.Lock()
+	hosts := make([]string, 0, len(r.addrs)+len(r.errs))
+	for host := range r.addrs {
+		hosts = append(hosts, host)
+	}
+	for host := range r.errs {
+		hosts = append(hosts, host)
+	}
+	r.lock.Unlock()
+
+	changed := false
+	for _, host := range hosts {
+		addrs, err := r.resolve(host)
+		if err != nil {
+			klog.Warningf("Error resolving ExternalName %v: %v", host, err)
+			r.lock.Lock()
+			if _, ok := r.errs[host]; ok {
+				r.errs[host] = err
+			}
+			r.lock.Unlock()
+			continue
+		}
+
+		r.lock.Lock()
+		if _, ok := r.errs[host]; ok {
+			klog.Infof("ExternalName %v is resolved to %v", host, addrs)
+			delete(r.errs, host)
+			r.addrs[host] = addrs
+			changed = true
+		} else if old, ok := r.addrs[host]; ok && !reflect.DeepEqual(old, addrs) {
+			klog.Infof("Addresses of ExternalName %v changed from %v to %v", host, old, addrs)
+			r.addrs[host] = addrs
+			changed = true
+		}
+		r.lock.Unlock()
+	}
+
+	if changed && r.onChange != nil {
+		r.onChange()
+	}
+}
+
+func (r *externalNameResolver) resolve(host string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defResolveTimeout)
+	defer cancel()
+
+	addrs, err := r.resolver.LookupHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(addrs)
+	return addrs, nil
+}
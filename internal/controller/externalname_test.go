@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeResolver resolves hosts from a table, lookups are blocked until
+// release is closed if it is set
+type fakeResolver struct {
+	lock    sync.Mutex
+	hosts   map[string][]string
+	lookups map[string]int
+	release chan struct{}
+}
+
+func newFakeResolver(hosts map[string][]string) *fakeResolver {
+	return &fakeResolver{
+		hosts:   hosts,
+		lookups: make(map[string]int),
+	}
+}
+
+func (f *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if f.release != nil {
+		<-f.release
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.lookups[host]++
+	addrs, ok := f.hosts[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+	return append([]string(nil), addrs...), nil
+}
+
+func (f *fakeResolver) set(host string, addrs []string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if addrs == nil {
+		delete(f.hosts, host)
+	} else {
+		f.hosts[host] = addrs
+	}
+}
+
+func (f *fakeResolver) lookupCount(host string) int {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.lookups[host]
+}
+
+func waitChange(t *testing.T, changes chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("onChange is not called")
+	}
+}
+
+func TestExternalNameLookup(t *testing.T) {
+	fake := newFakeResolver(map[string][]string{
+		"a.example.com": {"10.0.0.2", "10.0.0.1"},
+	})
+	fake.release = make(chan struct{})
+	changes := make(chan struct{}, 10)
+	r := newExternalNameResolver(fake, time.Hour, func() { changes <- struct{}{} })
+
+	// lookup of unknown host does not wait for resolving
+	addrs, err := r.Lookup("a.example.com")
+	if addrs != nil || err != nil {
+		t.Fatalf("Lookup() of unresolved host = %v, %v, want nil, nil", addrs, err)
+	}
+	// lookups before resolved do not resolve again
+	r.Lookup("a.example.com")
+
+	close(fake.release)
+	waitChange(t, changes)
+
+	addrs, err = r.Lookup("a.example.com")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if want := []string{"10.0.0.1", "10.0.0.2"}; !reflect.DeepEqual(addrs, want) {
+		t.Errorf("Lookup() = %v, want %v", addrs, want)
+	}
+	if n := fake.lookupCount("a.example.com"); n != 1 {
+		t.Errorf("host is resolved %d times, want 1", n)
+	}
+
+	// lookup of cached host does not resolve
+	r.Lookup("a.example.com")
+	if n := fake.lookupCount("a.example.com"); n != 1 {
+		t.Errorf("cached host is resolved %d times, want 1", n)
+	}
+}
+
+func TestExternalNameLookupError(t *testing.T) {
+	fake := newFakeResolver(map[string][]string{})
+	changes := make(chan struct{}, 10)
+	r := newExternalNameResolver(fake, time.Hour, func() { changes <- struct{}{} })
+
+	r.Lookup("b.example.com")
+	waitChange(t, changes)
+
+	if _, err := r.Lookup("b.example.com"); err == nil {
+		t.Fatalf("Lookup() of unknown host succeeded")
+	}
+
+	// failed host is resolved by refresh
+	fake.set("b.example.com", []string{"10.0.0.3"})
+	r.refresh()
+	waitChange(t, changes)
+
+	addrs, err := r.Lookup("b.example.com")
+	if err != nil || !reflect.DeepEqual(addrs, []string{"10.0.0.3"}) {
+		t.Errorf("Lookup() = %v, %v, want [10.0.0.3]", addrs, err)
+	}
+}
+
+func TestExternalNameRefresh(t *testing.T) {
+	fake := newFakeResolver(map[string][]string{
+		"a.example.com": {"10.0.0.1"},
+	})
+	changes := make(chan struct{}, 10)
+	r := newExternalNameResolver(fake, time.Hour, func() { changes <- struct{}{} })
+
+	r.Lookup("a.example.com")
+	waitChange(t, changes)
+
+	// unchanged addresses do not trigger onChange
+	r.refresh()
+	select {
+	case <-changes:
+		t.Errorf("onChange is called without changes")
+	default:
+	}
+
+	// addresses are kept if resolving fails
+	fake.set("a.example.com", nil)
+	r.refresh()
+	if addrs, _ := r.Lookup("a.example.com"); !reflect.DeepEqual(addrs, []string{"10.0.0.1"}) {
+		t.Errorf("Lookup() after failed refresh = %v, want [10.0.0.1]", addrs)
+	}
+
+	fake.set("a.example.com", []string{"10.0.0.4"})
+	r.refresh()
+	waitChange(t, changes)
+	if addrs, _ := r.Lookup("a.example.com"); !reflect.DeepEqual(addrs, []string{"10.0.0.4"}) {
+		t.Errorf("Lookup() after refresh = %v, want [10.0.0.4]", addrs)
+	}
+
+	// hosts not retained are dropped, and resolved again on lookup
+	r.Retain(map[string]bool{})
+	if addrs, err := r.Lookup("a.example.com"); addrs != nil || err != nil {
+		t.Errorf("Lookup() of dropped host = %v, %v, want nil, nil", addrs, err)
+	}
+	waitChange(t, changes)
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"github.com/baidu/ingress-bfe/internal/annotations"
+	"github.com/baidu/ingress-bfe/internal/config"
+)
+
+// addHeader adds mod_header rule of route matching the requests routed by
+// route. Headers are removed first, then set and added.
+func addHeader(cfg *config.Config, route ingressRoute) {
+	header := route.anns.Header
+	if header.IsEmpty() {
+		return
+	}
+
+	var actions []config.HeaderAction
+	actions = appendHeaderDels(actions, config.HeaderReqDel, header.RequestRemove)
+	actions = appendHeaderValues(actions, config.HeaderReqSet, header.RequestSet)
+	actions = appendHeaderValues(actions, config.HeaderReqAdd, header.RequestAdd)
+	actions = appendHeaderDels(actions, config.HeaderRspDel, header.ResponseRemove)
+	actions = appendHeaderValues(actions, config.HeaderRspSet, header.ResponseSet)
+	actions = appendHeaderValues(actions, config.HeaderRspAdd, header.ResponseAdd)
+
+	cfg.Header.AddRule(route.product, config.HeaderRule{
+		Cond:    route.moduleCond,
+		Actions: actions,
+		Last:    true,
+	})
+}
+
+func appendHeaderDels(actions []config.HeaderAction, cmd string, headers []string) []config.HeaderAction {
+	for _, header := range headers {
+		actions = append(actions, config.HeaderAction{
+			Cmd:    cmd,
+			Params: []string{header},
+		})
+	}
+	return actions
+}
+
+func appendHeaderValues(actions []config.HeaderAction, cmd string, headers []annotations.HeaderValue) []config.HeaderAction {
+	for _, header := range headers {
+		actions = append(actions, config.HeaderAction{
+			Cmd:    cmd,
+			Params: []string{header.Name, header.Value},
+		})
+	}
+	return actions
+}
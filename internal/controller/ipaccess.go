@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+)
+
+// addIPAccess adds mod_block rules of route blocking requests routed by
+// route from clients in denylist or not in allowlist
+func addIPAccess(cfg *config.Config, route ingressRoute, global globalConfig) {
+	access := route.anns.IPAccess
+	ingKey := fmt.Sprintf("%v/%v", route.ing.Namespace, route.ing.Name)
+
+	if len(access.Denylist) > 0 {
+		cfg.Block.AddRule(route.product, "denylist "+ingKey,
+			config.CondAnd(route.moduleCond, config.CondClientIPIn(access.Denylist, global.useForwardedClientIP)))
+	}
+	if len(access.Allowlist) > 0 {
+		cfg.Block.AddRule(route.product, "allowlist "+ingKey,
+			config.CondAnd(route.moduleCond, config.CondNot(config.CondClientIPIn(access.Allowlist, global.useForwardedClientIP))))
+	}
+}
+
+// addGlobalDenylist blocks clients in global denylist for all products. The
+// peer address of connections is also checked by the ip blocklist unless
+// real client address is used.
+func addGlobalDenylist(cfg *config.Config, global globalConfig) {
+	if len(global.denylist) == 0 {
+		return
+	}
+	if !global.useForwardedClientIP {
+		cfg.Block.AddIPBlocklist(global.denylist...)
+	}
+
+	cond := config.CondClientIPIn(global.denylist, global.useForwardedClientIP)
+	products := []string{cfg.HostRule.DefaultProduct}
+	for product := range cfg.HostRule.HostTags {
+		products = append(products, product)
+	}
+	for _, product := range products {
+		cfg.Block.AddRule(product, "global denylist", cond)
+	}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defMetricsReadTimeout  = 10 * time.Second
+	defMetricsWriteTimeout = 10 * time.Second
+)
+
+var (
+	// reloadPerformed counts reloads of each BFE config section
+	reloadPerformed = expvar.NewMap("bfe_reload_performed")
+	// reloadSkipped counts reloads skipped as the section is unchanged
+	reloadSkipped = expvar.NewMap("bfe_reload_skipped")
+	// reloadFailed counts failed reloads of each BFE config section
+	reloadFailed = expvar.NewMap("bfe_reload_failed")
+)
+
+// newMetricsServer returns the server of metrics and debug endpoints
+// listening on addr:
+//   - /debug/vars serves expvar variables, e.g. reload counters
+//   - /debug/config-versions lists retained BFE config versions
+//   - /debug/conflicts lists paths claimed by more than one Ingress
+func (b *BfeController) newMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/config-versions", func(w http.ResponseWriter, r *http.Request) {
+		versions, err := b.ConfigVersions()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("list config versions error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, versions)
+	})
+	mux.HandleFunc("/debug/conflicts", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, b.Conflicts())
+	})
+	return &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  defMetricsReadTimeout,
+		WriteTimeout: defMetricsWriteTimeout,
+	}
+}
+
+// writeJSON writes v as an indented JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("marshal response error: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"github.com/baidu/ingress-bfe/internal/annotations"
+	"github.com/baidu/ingress-bfe/internal/config"
+)
+
+// addRedirect adds mod_redirect rule of route matching the requests routed
+// by route. Permanent redirect takes precedence over temporal redirect,
+// which takes precedence over ssl redirect.
+func addRedirect(cfg *config.Config, route ingressRoute) {
+	redirect := route.anns.Redirect
+
+	var rule config.RedirectRule
+	switch {
+	case redirect.Permanent != "":
+		rule = config.RedirectRule{
+			Cond: route.moduleCond,
+			Actions: []config.RedirectAction{{
+				Cmd:    config.RedirectURLSet,
+				Params: []string{redirect.Permanent},
+			}},
+			Status: redirect.PermanentCode,
+		}
+
+	case redirect.Temporal != "":
+		rule = config.RedirectRule{
+			Cond: route.moduleCond,
+			Actions: []config.RedirectAction{{
+				Cmd:    config.RedirectURLSet,
+				Params: []string{redirect.Temporal},
+			}},
+			Status: redirect.TemporalCode,
+		}
+
+	case sslRedirect(route):
+		// redirect annotations are not parsed if ssl redirect is on by default
+		code := redirect.SSLRedirectCode
+		if code == 0 {
+			code = annotations.DefSSLRedirectCode
+		}
+		rule = config.RedirectRule{
+			Cond: config.CondAnd(route.moduleCond, config.CondNot(config.CondProtoSecure)),
+			Actions: []config.RedirectAction{{
+				Cmd:    config.RedirectSchemeSet,
+				Params: []string{"https"},
+			}},
+			Status: code,
+		}
+
+	default:
+		return
+	}
+
+	cfg.Redirect.AddRule(route.product, rule)
+}
+
+// sslRedirect returns true if http requests of route are redirected to
+// https, by default if spec.tls of ingress covers host of route
+func sslRedirect(route ingressRoute) bool {
+	if route.anns.Redirect.SSLRedirect != nil {
+		return *route.anns.Redirect.SSLRedirect
+	}
+	if route.product == config.DefaultProduct {
+		return false
+	}
+	for _, tls := range route.ing.Spec.TLS {
+		for _, host := range tls.Hosts {
+			if hostMatch(host, route.product) {
+				return true
+			}
+		}
+	}
+	return false
+}
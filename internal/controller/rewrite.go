@@ -0,0 +1,61 @@
+package controller
+
+import (
+	"strings"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/client-go/tools/record"
+)
+
+// addRewrite adds mod_rewrite rule of route matching the requests routed by
+// route
+func addRewrite(cfg *config.Config, route ingressRoute, recorder record.EventRecorder) {
+	rewrite := route.anns.Rewrite
+	if rewrite.IsEmpty() {
+		return
+	}
+
+	var actions []config.RewriteAction
+	if rewrite.StripPrefix {
+		switch route.pathType {
+		case networking.PathTypeExact:
+			actions = append(actions, config.RewriteAction{
+				Cmd:    config.RewritePathSet,
+				Params: []string{"/"},
+			})
+		case networking.PathTypePrefix:
+			if prefix := strings.TrimRight(route.path, "/"); prefix != "" {
+				actions = append(actions, config.RewriteAction{
+					Cmd:    config.RewritePathPrefixTrim,
+					Params: []string{prefix},
+				})
+			}
+		default:
+			recorder.Eventf(route.ing, apiv1.EventTypeWarning, "INVALID",
+				"Ignoring strip prefix of path %v: path of type %v has no prefix", route.path, route.pathType)
+		}
+	}
+	if rewrite.PathRegex != "" {
+		actions = append(actions, config.RewriteAction{
+			Cmd:    config.RewritePathRegexSet,
+			Params: []string{rewrite.PathRegex, rewrite.PathReplacement},
+		})
+	}
+	if rewrite.AddPrefix != "" {
+		actions = append(actions, config.RewriteAction{
+			Cmd:    config.RewritePathPrefixAdd,
+			Params: []string{rewrite.AddPrefix},
+		})
+	}
+	if len(actions) == 0 {
+		return
+	}
+
+	cfg.Rewrite.AddRule(route.product, config.RewriteRule{
+		Cond:    route.moduleCond,
+		Actions: actions,
+		Last:    true,
+	})
+}
@@ -0,0 +1,353 @@
+package controller
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/baidu/ingress-bfe/internal/annotations"
+	"github.com/baidu/ingress-bfe/internal/config"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// getConfiguration translates ingresses into BFE routing, cluster and tls config.
+// Ingresses must be sorted, rules of older ingress are added first.
+func (b *BfeController) getConfiguration(ingresses []*networking.Ingress) *config.Config {
+	cfg := config.NewConfig()
+	cfg.Version = config.NewVersion()
+
+	t := b.translate(cfg, ingresses, b.recorder)
+	endpoints, errs := b.addClusters(cfg, t.backends)
+	b.recordUnresolved(cfg, t.backends, errs, ingresses)
+	// addresses of ExternalName services no longer referenced are dropped
+	b.retainExternalNames(t.backends)
+	b.addCanarySplits(cfg, t.splits, endpoints)
+	b.setConflicts(t.owners.conflicts, ingresses)
+
+	return cfg
+}
+
+// translation is the result of translating ingresses besides config
+type translation struct {
+	// backends maps cluster to referenced service port
+	backends map[string]serviceBackend
+	// splits are clusters splitting traffic to canary
+	splits []canarySplit
+	// owners are owners of routes and conflicting paths
+	owners *routeOwners
+}
+
+// translate adds hosts, routes and tls config of ingresses to cfg, problems
+// of ingresses are recorded as Warning Events by recorder. Clusters are not
+// added.
+func (b *BfeController) translate(cfg *config.Config, ingresses []*networking.Ingress, recorder record.EventRecorder) *translation {
+	global := b.getGlobalConfig()
+	backends := make(map[string]serviceBackend)
+	fallbacks := make(map[string]string)
+	owners := newRouteOwners()
+	var routes []ingressRoute
+	var canaries []canaryRoute
+	for _, ing := range ingresses {
+		ingKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+		anns, err := annotations.Extract(ing)
+		if err != nil {
+			klog.Warningf("Ignoring Ingress %v: %v", ingKey, err)
+			recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Ignoring Ingress: %v", err)
+			continue
+		}
+		canary := anns.Canary
+		predicates := routerConds(anns.Router, anns.RouterCondition)
+
+		b.addTLS(cfg, ing, recorder)
+		if canary.Enabled {
+			checkCanaryAnnotations(ing, recorder)
+		} else {
+			b.addIngressBackend(cfg, ing, owners, fallbacks, backends)
+		}
+
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+
+			if err := validateHost(rule.Host); err != nil {
+				klog.Warningf("Ignoring rule of host %v of Ingress %v: %v", rule.Host, ingKey, err)
+				recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Ignoring rule of host %v: %v", rule.Host, err)
+				continue
+			}
+
+			product := productName(rule.Host)
+			cfg.AddSource(product, ingKey)
+			if rule.Host != "" {
+				cfg.HostRule.AddHost(product, rule.Host)
+			}
+
+			for _, path := range rule.HTTP.Paths {
+				cond, err := pathCond(path)
+				if err != nil {
+					klog.Warningf("Ignoring path %v of Ingress %v: %v", path.Path, ingKey, err)
+					recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Ignoring path %v: %v", path.Path, err)
+					continue
+				}
+
+				cluster := clusterName(ing.Namespace, path.Backend.ServiceName, path.Backend.ServicePort)
+				route := ingressRoute{
+					product:    product,
+					path:       path.Path,
+					pathType:   pathType(path),
+					regex:      isRegexPath(path),
+					pathCond:   cond,
+					cond:       routeCond(cond, predicates),
+					predicates: len(predicates),
+					cluster:    cluster,
+					ing:        ing,
+					anns:       anns,
+				}
+				switch {
+				case canary.Enabled:
+					// canary paths do not claim paths of primary ingress
+					canaries = append(canaries, canaryRoute{
+						ingressRoute: route,
+						canary:       canary,
+					})
+				case owners.claim(ing, rule.Host, path, route.cond):
+					routes = append(routes, route)
+				default:
+					// conflicts are reported when they first appear by
+					// setConflicts
+					continue
+				}
+
+				cfg.AddSource(cluster, ingKey)
+				backends[cluster] = serviceBackend{
+					namespace: ing.Namespace,
+					service:   path.Backend.ServiceName,
+					port:      path.Backend.ServicePort,
+				}
+			}
+		}
+	}
+
+	routes, splits := b.addCanaryRoutes(cfg, routes, canaries, recorder)
+	sortRoutes(routes)
+	// previous are conditions of primary routes matched before a route
+	previous := make(map[string][]string)
+	for _, route := range routes {
+		cfg.RouteRule.AddRule(route.product, config.RouteRule{
+			Cond:        route.cond,
+			ClusterName: route.cluster,
+		})
+		// module rules of primary route apply to its canary routes too,
+		// since condition of canary route implies that of primary route
+		if route.canary {
+			continue
+		}
+		moduleConds := []string{route.cond}
+		for _, cond := range previous[route.product] {
+			moduleConds = append(moduleConds, config.CondNot(cond))
+		}
+		route.moduleCond = config.CondAnd(moduleConds...)
+		previous[route.product] = append(previous[route.product], route.cond)
+
+		addRewrite(cfg, route, recorder)
+		addRedirect(cfg, route)
+		addHeader(cfg, route)
+		b.addAuthBasic(cfg, route, recorder)
+		addIPAccess(cfg, route, global)
+		addCORS(cfg, route)
+	}
+	// fallback routes are matched after all the paths
+	b.addDefaultBackend(cfg, fallbacks, backends)
+	addGlobalDenylist(cfg, global)
+
+	return &translation{
+		backends: backends,
+		splits:   splits,
+		owners:   owners,
+	}
+}
+
+// productName returns BFE product of host, each host is served by its own
+// product. A wildcard host like *.example.com is a product of its own too,
+// BFE host table prefers exact host to wildcard host, so a request of
+// foo.example.com is served by product foo.example.com if it exists.
+func productName(host string) string {
+	if host == "" {
+		return config.DefaultProduct
+	}
+	return host
+}
+
+// validateHost checks host of ingress rule, only a leading "*" label is
+// allowed as wildcard
+func validateHost(host string) error {
+	if isWildcardHost(host) {
+		host = host[2:]
+	}
+	if strings.Contains(host, "*") {
+		return fmt.Errorf("wildcard is only allowed as the first label")
+	}
+	return nil
+}
+
+// clusterName returns BFE cluster name of service port
+func clusterName(namespace, service string, port intstr.IntOrString) string {
+	return fmt.Sprintf("%s_%s_%s", namespace, service, port.String())
+}
+
+// ingressRoute is a route rule generated from an ingress path
+type ingressRoute struct {
+	product  string
+	path     string
+	pathType networking.PathType
+	// regex routes match by regular expression, see pathCond
+	regex bool
+	// pathCond matches the path only, cond is pathCond and predicates
+	pathCond string
+	cond     string
+	// predicates is the number of conditions besides path, routes with
+	// more predicates are matched first
+	predicates int
+	cluster    string
+	// canary routes are matched before primary route of the same path
+	canary bool
+	// moduleCond matches requests routed by the route, it is cond
+	// excluding routes matched before, so a module rule of a less
+	// specific route never applies to requests of another route
+	moduleCond string
+
+	ing  *networking.Ingress
+	anns *annotations.Annotations
+}
+
+// pathTypePriority orders routes of same path, exact match first
+var pathTypePriority = map[networking.PathType]int{
+	networking.PathTypeExact:                  0,
+	networking.PathTypePrefix:                 1,
+	networking.PathTypeImplementationSpecific: 2,
+}
+
+// routeRank groups routes in match order: exact and prefix routes, then
+// regex routes, then routes matching any path
+func routeRank(r ingressRoute) int {
+	switch {
+	case r.pathCond == config.CondDefault:
+		return 2
+	case r.regex:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// sortRoutes orders exact and prefix routes so that longer path is matched
+// first, and exact path before prefix of the same path. Length of a regex
+// says nothing about how specific it is, so regex routes are matched after
+// them in the order of ingresses, and routes matching any path are matched
+// last. Routes with more predicates are matched before the plain path route
+// of the same path. Otherwise the order of ingresses is kept.
+func sortRoutes(routes []ingressRoute) {
+	// regexOrder is the order of first route of each regex
+	regexOrder := make(map[string]int)
+	for _, r := range routes {
+		if _, ok := regexOrder[r.path]; r.regex && !ok {
+			regexOrder[r.path] = len(regexOrder)
+		}
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		ri, rj := routes[i], routes[j]
+		if routeRank(ri) != routeRank(rj) {
+			return routeRank(ri) < routeRank(rj)
+		}
+		if routeRank(ri) == 0 && len(ri.path) != len(rj.path) {
+			return len(ri.path) > len(rj.path)
+		}
+		if ri.regex && regexOrder[ri.path] != regexOrder[rj.path] {
+			return regexOrder[ri.path] < regexOrder[rj.path]
+		}
+		if ri.pathType != rj.pathType {
+			return pathTypePriority[ri.pathType] < pathTypePriority[rj.pathType]
+		}
+		return ri.predicates > rj.predicates
+	})
+}
+
+// routerConds returns conditions of router annotations, a raw condition is
+// enclosed in parentheses
+func routerConds(router annotations.Router, rawCond string) []string {
+	var conds []string
+	if router.Header != nil {
+		conds = append(conds, config.CondHeaderValueIn(router.Header.Key, router.Header.Value))
+	}
+	if router.Cookie != nil {
+		conds = append(conds, config.CondCookieValueIn(router.Cookie.Key, router.Cookie.Value))
+	}
+	if router.Query != nil {
+		conds = append(conds, config.CondQueryValueIn(router.Query.Key, router.Query.Value))
+	}
+	if rawCond != "" {
+		conds = append(conds, "("+rawCond+")")
+	}
+	return conds
+}
+
+// routeCond returns condition of route, it is path condition and predicates
+func routeCond(pathCond string, predicates []string) string {
+	return config.CondAnd(append([]string{pathCond}, predicates...)...)
+}
+
+// pathType returns PathType of ingress path, Prefix if not defined
+func pathType(path networking.HTTPIngressPath) networking.PathType {
+	if path.PathType == nil {
+		return networking.PathTypePrefix
+	}
+	return *path.PathType
+}
+
+// isRegexPath returns true if path is ImplementationSpecific and contains
+// regular expression metacharacters
+func isRegexPath(path networking.HTTPIngressPath) bool {
+	return pathType(path) == networking.PathTypeImplementationSpecific &&
+		regexp.QuoteMeta(path.Path) != path.Path
+}
+
+// pathCond returns BFE condition of ingress path:
+//   - Exact matches the path exactly
+//   - Prefix matches the path element by element, so /foo matches /foo and
+//     /foo/bar but not /foobar. A trailing slash is ignored.
+//   - ImplementationSpecific, the default of networking/v1beta1, matches a
+//     prefix of the request path as before, so /foo matches /foobar too. A
+//     path with regular expression metacharacters is a regular expression
+//     (RE2 syntax) matching the start of the request path, e.g. /v[0-9]+/
+func pathCond(path networking.HTTPIngressPath) (string, error) {
+	switch pathType(path) {
+	case networking.PathTypeExact:
+		return config.CondPathIn(path.Path), nil
+
+	case networking.PathTypeImplementationSpecific:
+		if !isRegexPath(path) {
+			if path.Path == "" || path.Path == "/" {
+				return config.CondDefault, nil
+			}
+			return config.CondPathPrefixIn(path.Path), nil
+		}
+		pattern := fmt.Sprintf("^(?:%s)", path.Path)
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("invalid regular expression: %v", err)
+		}
+		return config.CondPathRegMatch(pattern), nil
+
+	default:
+		prefix := strings.TrimRight(path.Path, "/")
+		if prefix == "" {
+			return config.CondDefault, nil
+		}
+		return config.CondPathElementPrefixIn(prefix), nil
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	networking "k8s.io/api/networking/v1beta1"
+)
+
+func newTestPath(path string, pathType networking.PathType) networking.HTTPIngressPath {
+	return networking.HTTPIngressPath{
+		Path:     path,
+		PathType: &pathType,
+	}
+}
+
+func TestPathCond(t *testing.T) {
+	tests := []struct {
+		path     string
+		pathType networking.PathType
+		want     string
+		wantErr  bool
+	}{
+		{path: "/foo", pathType: networking.PathTypeExact, want: config.CondPathIn("/foo")},
+		{path: "/foo/", pathType: networking.PathTypePrefix, want: config.CondPathElementPrefixIn("/foo")},
+		{path: "/", pathType: networking.PathTypePrefix, want: config.CondDefault},
+		{path: "/", pathType: networking.PathTypeImplementationSpecific, want: config.CondDefault},
+		{path: "", pathType: networking.PathTypeImplementationSpecific, want: config.CondDefault},
+		{path: "/foo", pathType: networking.PathTypeImplementationSpecific, want: config.CondPathPrefixIn("/foo")},
+		{path: "/v[0-9]+/", pathType: networking.PathTypeImplementationSpecific, want: config.CondPathRegMatch("^(?:/v[0-9]+/)")},
+		{path: "/v[0-9", pathType: networking.PathTypeImplementationSpecific, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := pathCond(newTestPath(tt.path, tt.pathType))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("pathCond(%q, %v) error = %v, wantErr %v", tt.path, tt.pathType, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("pathCond(%q, %v) = %v, want %v", tt.path, tt.pathType, got, tt.want)
+		}
+	}
+}
+
+func TestSortRoutes(t *testing.T) {
+	newRoute := func(path string, pathType networking.PathType, predicates int) ingressRoute {
+		p := newTestPath(path, pathType)
+		cond, err := pathCond(p)
+		if err != nil {
+			t.Fatalf("pathCond(%q) error: %v", path, err)
+		}
+		return ingressRoute{
+			path:       path,
+			pathType:   pathType,
+			regex:      isRegexPath(p),
+			pathCond:   cond,
+			predicates: predicates,
+			cluster:    path + "/" + string(pathType),
+		}
+	}
+
+	routes := []ingressRoute{
+		newRoute("/", networking.PathTypeImplementationSpecific, 0),
+		newRoute("/a.*/long", networking.PathTypeImplementationSpecific, 0),
+		newRoute("/foo", networking.PathTypePrefix, 0),
+		newRoute("/b+", networking.PathTypeImplementationSpecific, 0),
+		newRoute("/foo", networking.PathTypeExact, 0),
+		newRoute("/b+", networking.PathTypeImplementationSpecific, 1),
+		newRoute("/foo/bar", networking.PathTypeImplementationSpecific, 0),
+		newRoute("/foo", networking.PathTypePrefix, 1),
+	}
+	sortRoutes(routes)
+
+	var got []string
+	for _, r := range routes {
+		got = append(got, r.cluster)
+	}
+	want := []string{
+		"/foo/bar/ImplementationSpecific",
+		"/foo/Exact",
+		"/foo/Prefix",
+		"/foo/Prefix",
+		"/a.*/long/ImplementationSpecific",
+		"/b+/ImplementationSpecific",
+		"/b+/ImplementationSpecific",
+		"//ImplementationSpecific",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("sortRoutes() = %v, want %v", got, want)
+	}
+	if routes[2].predicates != 1 || routes[5].predicates != 1 {
+		t.Errorf("routes with predicates are not matched before plain routes of the same path")
+	}
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	"github.com/baidu/ingress-bfe/internal/store"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+)
+
+// addTLS adds certificates referenced by ingress spec.tls, and adds tls hosts
+// to tls rules of their products. Certificates which can not be found are
+// recorded as Warning Events by recorder.
+func (b *BfeController) addTLS(cfg *config.Config, ing *networking.Ingress, recorder record.EventRecorder) {
+	ingKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)
+	for _, tls := range ing.Spec.TLS {
+		if tls.SecretName == "" {
+			// use certificates of other ingresses covering the hosts
+			for _, host := range tls.Hosts {
+				cert := b.findCert(host)
+				if cert == nil {
+					klog.Warningf("No SSL certificate found for host %v of Ingress %v", host, ingKey)
+					recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "No SSL certificate found for host %v", host)
+					continue
+				}
+				b.addCert(cfg, ingKey, fmt.Sprintf("%v/%v", cert.Namespace, cert.Name), cert, host)
+			}
+			continue
+		}
+
+		key := fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName)
+		cert, err := b.store.GetLocalSSLCert(key)
+		if err != nil {
+			klog.Warningf("Error getting SSL certificate %v of Ingress %v: %v", key, ingKey, err)
+			recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Error getting SSL certificate %v: %v", key, err)
+			continue
+		}
+		if cert.PemFileName == "" {
+			klog.Warningf("Secret %v of Ingress %v contains no keypair", key, ingKey)
+			recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Secret %v contains no keypair", key)
+			continue
+		}
+		b.addCert(cfg, ingKey, key, cert, tls.Hosts...)
+	}
+}
+
+// addCert adds certificate of secret key, and adds hosts to tls rules of
+// their products
+func (b *BfeController) addCert(cfg *config.Config, ingKey, key string, cert *store.SSLCert, hosts ...string) {
+	// pem contains both certificate and key
+	cfg.ServerCert.AddCert(certName(key), []byte(cert.PemCertKey))
+	cfg.AddSource(certName(key), ingKey)
+
+	for _, host := range hosts {
+		if !certMatchHost(cert, host) {
+			klog.Warningf("SSL certificate %v does not contain a name for host %v", key, host)
+		}
+		cfg.TLSRule.AddHost(productName(host), host)
+		cfg.AddSource(productName(host), ingKey)
+	}
+}
+
+// findCert returns a local certificate whose name matches host, certificate
+// of exact name is preferred over wildcard one
+func (b *BfeController) findCert(host string) *store.SSLCert {
+	certs := b.store.ListLocalSSLCerts()
+	sort.Slice(certs, func(i, j int) bool {
+		ki := certs[i].Namespace + "/" + certs[i].Name
+		kj := certs[j].Namespace + "/" + certs[j].Name
+		return ki < kj
+	})
+
+	var wildcard *store.SSLCert
+	for _, cert := range certs {
+		if cert.PemFileName == "" {
+			continue
+		}
+		for _, cn := range cert.CN {
+			if cn == host {
+				return cert
+			}
+			if wildcard == nil && hostMatch(cn, host) {
+				wildcard = cert
+			}
+		}
+	}
+	return wildcard
+}
+
+// certName returns BFE certificate name of secret namespace/name
+func certName(secretKey string) string {
+	return strings.Replace(secretKey, "/", "-", -1)
+}
+
+// certMatchHost returns true if one of the names of certificate matches host
+func certMatchHost(cert *store.SSLCert, host string) bool {
+	for _, cn := range cert.CN {
+		if hostMatch(cn, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostMatch returns true if host matches pattern. A wildcard pattern like
+// *.example.com matches exactly one label, e.g. foo.example.com, but not
+// example.com or foo.bar.example.com. A wildcard host only matches the
+// same wildcard pattern.
+func hostMatch(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	if !isWildcardHost(pattern) || isWildcardHost(host) {
+		return false
+	}
+	dot := strings.Index(host, ".")
+	return dot > 0 && host[dot:] == pattern[1:]
+}
+
+// isWildcardHost returns true if host is like *.example.com
+func isWildcardHost(host string) bool {
+	return strings.HasPrefix(host, "*.")
+}
@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/baidu/ingress-bfe/internal/config"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1beta1"
+	"k8s.io/klog"
+)
+
+// testConfiguration validates config rendered into conf directory dir by
+// loading the files in go, since bfe has no mode to only check config.
+// Errors are recorded as events of the ingresses they come from.
+func (b *BfeController) testConfiguration(cfg *config.Config, dir string, ingresses []*networking.Ingress) error {
+	errs := config.Validate(dir)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	b.recordValidationErrors(cfg, errs, ingresses)
+	return fmt.Errorf("bfe config version %v is invalid, %d errors found", cfg.Version, len(errs))
+}
+
+// recordValidationErrors records each error as a warning event of ingresses
+// which generated the invalid config
+func (b *BfeController) recordValidationErrors(cfg *config.Config, errs []config.ValidationError, ingresses []*networking.Ingress) {
+	ingMap := make(map[string]*networking.Ingress, len(ingresses))
+	for _, ing := range ingresses {
+		ingMap[fmt.Sprintf("%v/%v", ing.Namespace, ing.Name)] = ing
+	}
+
+	for _, e := range errs {
+		klog.Warningf("invalid bfe config: %v", e)
+		for _, key := range cfg.Sources[e.Name] {
+			if ing, ok := ingMap[key]; ok {
+				b.recorder.Eventf(ing, apiv1.EventTypeWarning, "INVALID", "Invalid bfe config: %v", e)
+			}
+		}
+	}
+}
@@ -51,6 +51,18 @@ func (ch *ConfigMapResourceEventHandler) OnUpdate(old, cur interface{}) {
 
 //OnDelete handler endpoints delete event
 func (ch *ConfigMapResourceEventHandler) OnDelete(obj interface{}) {
+	cfgMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		if cfgMap, ok = tombstone.Obj.(*corev1.ConfigMap); !ok {
+			return
+		}
+	}
+	// global settings of a deleted ConfigMap are dropped on resync
+	ch.handleCfgMapEvent(cfgMap)
 }
 
 func (ch *ConfigMapResourceEventHandler) handleCfgMapEvent(cfgMap *corev1.ConfigMap) {
@@ -132,12 +132,12 @@ func toIngress(obj interface{}) (*networking.Ingress, bool) {
 			return nil, false
 		}
 
-		SetDefaultNGINXPathType(ing)
+		SetDefaultBFEPathType(ing)
 		return ing, true
 	}
 
 	if ing, ok := obj.(*networking.Ingress); ok {
-		SetDefaultNGINXPathType(ing)
+		SetDefaultBFEPathType(ing)
 		return ing, true
 	}
 
@@ -206,8 +206,10 @@ func IsValid(ing *networking.Ingress) bool {
 	return IngressClass.Name == *ing.Spec.IngressClassName
 }
 
-// SetDefaultNGINXPathType sets a default PathType when is not defined.
-func SetDefaultNGINXPathType(ing *networking.Ingress) {
+// SetDefaultBFEPathType sets PathType Prefix when is not defined.
+// ImplementationSpecific is kept, BFE matches it as a path prefix or a
+// regular expression.
+func SetDefaultBFEPathType(ing *networking.Ingress) {
 	for _, rule := range ing.Spec.Rules {
 		if rule.IngressRuleValue.HTTP == nil {
 			continue
@@ -218,10 +220,6 @@ func SetDefaultNGINXPathType(ing *networking.Ingress) {
 			if p.PathType == nil {
 				p.PathType = &[]networking.PathType{networking.PathTypePrefix}[0]
 			}
-
-			if *p.PathType == networking.PathTypeImplementationSpecific {
-				p.PathType = &[]networking.PathType{networking.PathTypePrefix}[0]
-			}
 		}
 	}
 }
@@ -12,6 +12,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -333,7 +335,11 @@ func CheckCACert(caBytes []byte) ([]*x509.Certificate, error) {
 func SSLCertOnDisk(name string, sslCert *SSLCert) (string, error) {
 	pemFileName, _ := getPemFileName(name)
 
-	err := ioutil.WriteFile(pemFileName, []byte(sslCert.PemCertKey), ReadWriteByUser)
+	if err := os.MkdirAll(DefaultSSLDirectory, ReadWriteByUser); err != nil {
+		return "", fmt.Errorf("could not create directory %v: %v", DefaultSSLDirectory, err)
+	}
+
+	err := writeFileAtomic(pemFileName, []byte(sslCert.PemCertKey), ReadWriteByUser)
 	if err != nil {
 		return "", fmt.Errorf("could not create PEM certificate file %v: %v", pemFileName, err)
 	}
@@ -341,6 +347,31 @@ func SSLCertOnDisk(name string, sslCert *SSLCert) (string, error) {
 	return pemFileName, nil
 }
 
+// writeFileAtomic writes data into a temporary file in the same directory,
+// then renames it to filename, so readers never see a partially written file
+func writeFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename))
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, filename)
+}
+
 // getPemFileName returns absolute file path and file name of pem cert related to given fullSecretName
 func getPemFileName(fullSecretName string) (string, string) {
 	pemName := fmt.Sprintf("%v.pem", fullSecretName)
@@ -367,7 +398,7 @@ func ConfigureCACertWithCertAndKey(name string, ca []byte, sslCert *SSLCert) err
 		return fmt.Errorf("could not write ca data to cert file %v: %v", sslCert.CAFileName, err)
 	}
 
-	return ioutil.WriteFile(sslCert.CAFileName, buffer.Bytes(), 0644)
+	return writeFileAtomic(sslCert.CAFileName, buffer.Bytes(), 0644)
 }
 
 // ConfigureCRL creates a CRL file and append it into the SSLCert
@@ -390,7 +421,7 @@ func ConfigureCRL(name string, crl []byte, sslCert *SSLCert) error {
 		return fmt.Errorf(err.Error())
 	}
 
-	err = ioutil.WriteFile(crlFileName, crl, 0644)
+	err = writeFileAtomic(crlFileName, crl, 0644)
 	if err != nil {
 		return fmt.Errorf("could not write CRL file %v: %v", crlFileName, err)
 	}
@@ -421,7 +452,7 @@ func ConfigureCACert(name string, ca []byte, sslCert *SSLCert) error {
 	caName := fmt.Sprintf("ca-%v.pem", name)
 	fileName := fmt.Sprintf("%v/%v", DefaultSSLDirectory, caName)
 
-	err := ioutil.WriteFile(fileName, ca, 0644)
+	err := writeFileAtomic(fileName, ca, 0644)
 	if err != nil {
 		return fmt.Errorf("could not write CA file %v: %v", fileName, err)
 	}
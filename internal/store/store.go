@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/baidu/ingress-bfe/internal/annotations"
 	"github.com/eapache/channels"
 	corev1 "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1beta1"
@@ -13,8 +14,6 @@ import (
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/kubernetes/scheme"
-	clientcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
@@ -37,6 +36,10 @@ type Store interface {
 	Run(stopCh chan struct{})
 	// GetLocalSSLCert returns the local copy of a SSLCert
 	GetLocalSSLCert(name string) (*SSLCert, error)
+	// ListLocalSSLCerts returns all local copies of SSLCert
+	ListLocalSSLCerts() []*SSLCert
+	// GetConfigMap returns the ConfigMap matching key
+	GetConfigMap(key string) (*corev1.ConfigMap, error)
 }
 
 //EventType name of event type
@@ -118,12 +121,13 @@ type K8sStore struct {
 	syncSecretMu *sync.Mutex
 }
 
-//NewStore create a new K8sStore
+//NewStore create a new K8sStore, events of resources are recorded by recorder
 func NewStore(
 	kubeClient kubernetes.Interface,
 	namespace string,
 	resycPeriod time.Duration,
 	updateCh *channels.RingChannel,
+	recorder record.EventRecorder,
 ) (store *K8sStore) {
 	store = &K8sStore{
 		informers:        &Informer{},
@@ -134,15 +138,6 @@ func NewStore(
 		syncSecretMu:     &sync.Mutex{},
 	}
 
-	eventBroadcaster := record.NewBroadcaster()
-	eventBroadcaster.StartLogging(klog.Infof)
-	eventBroadcaster.StartRecordingToSink(&clientcorev1.EventSinkImpl{
-		Interface: kubeClient.CoreV1().Events(namespace),
-	})
-	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
-		Component: "bfe-ingress-controller",
-	})
-
 	// As we currently do not filter out kubernetes objects we list, we can
 	// retrieve a huge amount of data from the API server.
 	// In a cluster using HELM < v3 configmaps are used to store binary data.
@@ -183,7 +178,7 @@ func NewStore(
 		recorder: recorder,
 	})
 
-	store.informers.Service = informerFactory.Core().V1().Secrets().Informer()
+	store.informers.Service = informerFactory.Core().V1().Services().Informer()
 	store.listers.Service.Store = store.informers.Service.GetStore()
 	store.informers.Service.AddEventHandler(&ServiceResourceEventHandler{
 		updateCh: store.updateCh,
@@ -232,7 +227,13 @@ func (s *K8sStore) ListIngresses(filter IngressFilterFunc) []*networking.Ingress
 
 		ingresses = append(ingresses, ing)
 	}
-	// sort Ingresses using the CreationTimestamp field
+	SortIngresses(ingresses)
+
+	return ingresses
+}
+
+// SortIngresses sorts ingresses by CreationTimestamp, the oldest first
+func SortIngresses(ingresses []*networking.Ingress) {
 	sort.SliceStable(ingresses, func(i, j int) bool {
 		ir := ingresses[i].CreationTimestamp
 		jr := ingresses[j].CreationTimestamp
@@ -244,8 +245,6 @@ func (s *K8sStore) ListIngresses(filter IngressFilterFunc) []*networking.Ingress
 		}
 		return ir.Before(&jr)
 	})
-
-	return ingresses
 }
 
 func (s *K8sStore) updateSecretIngressMap(ing *networking.Ingress) {
@@ -265,6 +264,10 @@ func (s *K8sStore) updateSecretIngressMap(ing *networking.Ingress) {
 			refSecrets = append(refSecrets, secrKey)
 		}
 	}
+	// secret of basic authentication
+	if secrKey, err := annotations.GetAuthSecret(ing); err == nil && secrKey != "" {
+		refSecrets = append(refSecrets, secrKey)
+	}
 	// populate map with all secret references
 	s.secretIngressMap.Insert(key, refSecrets...)
 }
@@ -274,6 +277,20 @@ func (s *K8sStore) GetLocalSSLCert(key string) (*SSLCert, error) {
 	return s.sslStore.ByKey(key)
 }
 
+// ListLocalSSLCerts returns all local copies of SSLCert
+func (s *K8sStore) ListLocalSSLCerts() []*SSLCert {
+	var certs []*SSLCert
+	for _, item := range s.sslStore.List() {
+		certs = append(certs, item.(*SSLCert))
+	}
+	return certs
+}
+
+// GetConfigMap returns the ConfigMap matching key
+func (s *K8sStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
+	return s.listers.ConfigMap.ByKey(key)
+}
+
 //syncSecrets 产生更新证书Event
 func (s *K8sStore) syncSecrets(ing *networking.Ingress) {
 	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(ing)